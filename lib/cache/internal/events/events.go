@@ -0,0 +1,80 @@
+// Package events holds the subscribers of events shared by the caches
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SubscribeChannel subscribes a channel of the given size with subscribe, events are dropped when the channel is full.
+// The channel is closed by unsubscribe
+func SubscribeChannel[E any, H ~func(E)](subscribe func(handler H) func(), size int) (events <-chan E, unsubscribe func()) {
+	ch := make(chan E, size)
+	cancel := subscribe(func(event E) {
+		select {
+		case ch <- event:
+		default:
+		}
+	})
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			// No event is being sent once cancel returns
+			cancel()
+			close(ch)
+		})
+	}
+}
+
+// Subscribers holds handlers of events of type E, the zero value is ready to use
+type Subscribers[E any] struct {
+	lock     sync.RWMutex
+	handlers map[uint64]func(E)
+	nextID   uint64
+	active   int32
+}
+
+// Enabled tells whether there is a handler, so events need not be built without one
+func (_this *Subscribers[E]) Enabled() bool {
+	return atomic.LoadInt32(&_this.active) > 0
+}
+
+// Subscribe adds handler until unsubscribe is called
+func (_this *Subscribers[E]) Subscribe(handler func(E)) (unsubscribe func()) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	if _this.handlers == nil {
+		_this.handlers = make(map[uint64]func(E))
+	}
+	id := _this.nextID
+	_this.nextID++
+	_this.handlers[id] = handler
+	atomic.AddInt32(&_this.active, 1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			_this.lock.Lock()
+			defer _this.lock.Unlock()
+
+			delete(_this.handlers, id)
+			atomic.AddInt32(&_this.active, -1)
+		})
+	}
+}
+
+// Emit calls every handler with event
+func (_this *Subscribers[E]) Emit(event E) {
+	if !_this.Enabled() {
+		return
+	}
+
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	for _, handler := range _this.handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,28 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestSubscribers(t *testing.T) {
+	var subscribers Subscribers[string]
+	if subscribers.Enabled() {
+		t.Errorf("subscribers without a handler should not be enabled")
+	}
+
+	events, unsubscribe := SubscribeChannel(subscribers.Subscribe, 1)
+	if !subscribers.Enabled() {
+		t.Errorf("subscribers with a handler should be enabled")
+	}
+	subscribers.Emit("a")
+	if event := <-events; event != "a" {
+		t.Errorf("bad event: %v", event)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if subscribers.Enabled() {
+		t.Errorf("subscribers should not be enabled after unsubscribe")
+	}
+	subscribers.Emit("b")
+}
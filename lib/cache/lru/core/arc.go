@@ -0,0 +1,220 @@
+package core
+
+import (
+	"time"
+)
+
+// ARC implements a non-thread safe fixed size Adaptive Replacement Cache, t1 holds entries used once and
+// t2 entries used repeatedly, b1 and b2 remember keys evicted from them to adapt the target size p of t1
+type ARC struct {
+	size int
+	p    int
+
+	t1 *LRU
+	t2 *LRU
+	b1 *LRU
+	b2 *LRU
+
+	onEvict EvictReasonCallback
+}
+
+// NewARC constructs an ARC cache of the given size with default expire
+func NewARC(size int, expire time.Duration, onEvict EvictReasonCallback) *ARC {
+	return &ARC{
+		size:    size,
+		t1:      newList(size, expire, onEvict),
+		t2:      newList(size, expire, onEvict),
+		b1:      newList(size, 0, nil),
+		b2:      newList(size, 0, nil),
+		onEvict: onEvict,
+	}
+}
+
+func (_this *ARC) Add(key, value interface{}, expiration time.Duration) bool {
+	return _this.AddWithSoftExpiration(key, value, 0, expiration)
+}
+
+func (_this *ARC) AddWithSoftExpiration(key, value interface{}, softExpiration, expiration time.Duration) (evicted bool) {
+	e := _this.t1.newEntry(key, value, softExpiration, expiration)
+
+	// A recent entry is set again, it is used frequently
+	if _, ok := _this.t1.take(key); ok {
+		_this.t2.put(e)
+		return false
+	}
+
+	if _, ok := _this.t2.peekEntry(key); ok {
+		_this.t2.put(e)
+		return false
+	}
+
+	// A key evicted from t1 is back, t1 should be larger
+	if _, ok := _this.b1.peekEntry(key); ok {
+		delta := 1
+		if b1Len, b2Len := _this.b1.len(), _this.b2.len(); b2Len > b1Len {
+			delta = b2Len / b1Len
+		}
+		_this.p += delta
+		if _this.p > _this.size {
+			_this.p = _this.size
+		}
+
+		if _this.t1.len()+_this.t2.len() >= _this.size {
+			evicted = _this.replace(false)
+		}
+
+		_this.b1.take(key)
+		_this.t2.put(e)
+		return
+	}
+
+	// A key evicted from t2 is back, t2 should be larger
+	if _, ok := _this.b2.peekEntry(key); ok {
+		delta := 1
+		if b1Len, b2Len := _this.b1.len(), _this.b2.len(); b1Len > b2Len {
+			delta = b1Len / b2Len
+		}
+		_this.p -= delta
+		if _this.p < 0 {
+			_this.p = 0
+		}
+
+		if _this.t1.len()+_this.t2.len() >= _this.size {
+			evicted = _this.replace(true)
+		}
+
+		_this.b2.take(key)
+		_this.t2.put(e)
+		return
+	}
+
+	if _this.t1.len()+_this.t2.len() >= _this.size {
+		evicted = _this.replace(false)
+	}
+
+	// Keep the ghost lists within their share
+	if _this.b1.len() > _this.size-_this.p {
+		_this.b1.takeOldest()
+	}
+	if _this.b2.len() > _this.p {
+		_this.b2.takeOldest()
+	}
+
+	_this.t1.put(e)
+	return
+}
+
+// replace evicts an entry from t1 or t2 depending on the target size of t1 and remembers its key
+func (_this *ARC) replace(b2ContainsKey bool) bool {
+	t1Len := _this.t1.len()
+	if t1Len > 0 && (t1Len > _this.p || (t1Len == _this.p && b2ContainsKey) || _this.t2.len() == 0) {
+		e, _ := _this.t1.takeOldest()
+		_this.b1.put(ghost(e))
+		evictEntry(_this.onEvict, e)
+		return true
+	}
+
+	e, ok := _this.t2.takeOldest()
+	if !ok {
+		return false
+	}
+	_this.b2.put(ghost(e))
+	evictEntry(_this.onEvict, e)
+	return true
+}
+
+func (_this *ARC) Get(key interface{}) (value interface{}, ok bool) {
+	value, _, ok = _this.GetWithStale(key)
+	return
+}
+
+func (_this *ARC) GetWithStale(key interface{}) (value interface{}, stale, ok bool) {
+	if e, ok := getFromList(_this.t1, key); ok {
+		_this.t1.take(key)
+		_this.t2.put(e)
+		return e.value, e.IsStale(), true
+	}
+
+	return _this.t2.GetWithStale(key)
+}
+
+func (_this *ARC) Contains(key interface{}) bool {
+	return _this.t1.Contains(key) || _this.t2.Contains(key)
+}
+
+func (_this *ARC) Peek(key interface{}) (value interface{}, ok bool) {
+	if value, ok = _this.t1.Peek(key); ok {
+		return
+	}
+	return _this.t2.Peek(key)
+}
+
+func (_this *ARC) Remove(key interface{}) bool {
+	_this.b1.take(key)
+	_this.b2.take(key)
+	if _this.t1.Remove(key) {
+		return true
+	}
+	return _this.t2.Remove(key)
+}
+
+// RemoveOldest removes the oldest entry of t1, or the oldest entry of t2 if t1 is empty
+func (_this *ARC) RemoveOldest() (key, value interface{}, ok bool) {
+	if key, value, ok = _this.t1.RemoveOldest(); ok {
+		return
+	}
+	return _this.t2.RemoveOldest()
+}
+
+// GetOldest returns the oldest entry of t1, or the oldest entry of t2 if t1 is empty
+func (_this *ARC) GetOldest() (key, value interface{}, ok bool) {
+	if key, value, ok = _this.t1.GetOldest(); ok {
+		return
+	}
+	return _this.t2.GetOldest()
+}
+
+// Keys returns keys of t1 then keys of t2, from oldest to newest in each list
+func (_this *ARC) Keys() []interface{} {
+	return append(_this.t1.Keys(), _this.t2.Keys()...)
+}
+
+// Entries returns entries of t1 then entries of t2, from oldest to newest in each list
+func (_this *ARC) Entries() []Entry {
+	return append(_this.t1.Entries(), _this.t2.Entries()...)
+}
+
+func (_this *ARC) Len() int {
+	return _this.t1.Len() + _this.t2.Len()
+}
+
+func (_this *ARC) Purge() {
+	_this.t1.Purge()
+	_this.t2.Purge()
+	_this.b1.Purge()
+	_this.b2.Purge()
+	_this.p = 0
+}
+
+func (_this *ARC) Resize(size int) (evicted int) {
+	for _this.t1.len()+_this.t2.len() > size && _this.replace(false) {
+		evicted++
+	}
+
+	_this.size = size
+	if _this.p > size {
+		_this.p = size
+	}
+	for _, l := range []*LRU{_this.t1, _this.t2, _this.b1, _this.b2} {
+		l.Resize(size)
+	}
+	return
+}
+
+func (_this *ARC) Cost() int64 {
+	return _this.t1.Cost() + _this.t2.Cost()
+}
+
+func (_this *ARC) RemoveExpired() int {
+	return _this.t1.RemoveExpired() + _this.t2.RemoveExpired()
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"container/heap"
 	"container/list"
 	"errors"
 	"time"
@@ -12,10 +13,18 @@ type LRUCache interface {
 	// updates the "recently used"-ness of the key.
 	Add(key, value interface{}, expiration time.Duration) bool
 
+	// Adds a value to the cache which becomes stale after softExpiration and expires after expiration,
+	// returns true if an eviction occurred and updates the "recently used"-ness of the key.
+	AddWithSoftExpiration(key, value interface{}, softExpiration, expiration time.Duration) bool
+
 	// Returns key's value from the cache and
 	// updates the "recently used"-ness of the key. #value, isFound
 	Get(key interface{}) (value interface{}, ok bool)
 
+	// Returns key's value from the cache, whether it is stale and
+	// updates the "recently used"-ness of the key. #value, isStale, isFound
+	GetWithStale(key interface{}) (value interface{}, stale, ok bool)
+
 	// Checks if a key exists in cache without updating the recent-ness.
 	Contains(key interface{}) (ok bool)
 
@@ -31,10 +40,13 @@ type LRUCache interface {
 	// Returns the oldest entry from the cache. #key, value, isFound
 	GetOldest() (interface{}, interface{}, bool)
 
-	// Returns a slice of the keys in the cache, from oldest to newest.
+	// Returns a slice of the keys in the cache which are not expired, from oldest to newest.
 	Keys() []interface{}
 
-	// Returns the number of items in the cache.
+	// Returns the entries in the cache which are not expired, from oldest to newest.
+	Entries() []Entry
+
+	// Returns the number of items in the cache which are not expired.
 	Len() int
 
 	// Clears all cache entries.
@@ -42,25 +54,168 @@ type LRUCache interface {
 
 	// Resizes cache, returning number evicted
 	Resize(int) int
+
+	// Returns the total cost of entries in the cache.
+	Cost() int64
+
+	// Removes expired entries, returning number removed
+	RemoveExpired() int
+}
+
+// EvictReason tells why an entry has left the cache
+type EvictReason int
+
+const (
+	// EvictReasonExpired is used when an entry has passed its expiration
+	EvictReasonExpired EvictReason = iota
+	// EvictReasonCapacity is used when an entry is evicted to respect the size or the max cost
+	EvictReasonCapacity
+	// EvictReasonRemoved is used when an entry is removed explicitly
+	EvictReasonRemoved
+	// EvictReasonPurged is used when the cache is purged
+	EvictReasonPurged
+)
+
+func (_this EvictReason) String() string {
+	switch _this {
+	case EvictReasonExpired:
+		return "expired"
+	case EvictReasonCapacity:
+		return "capacity"
+	case EvictReasonRemoved:
+		return "removed"
+	case EvictReasonPurged:
+		return "purged"
+	default:
+		return "unknown"
+	}
 }
 
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback func(key interface{}, value interface{})
 
-// LRU implements a non-thread safe fixed size LRU cache
+// EvictReasonCallback is used to get a callback with the reason when a cache entry is evicted
+type EvictReasonCallback func(key interface{}, value interface{}, reason EvictReason)
+
+// withoutReason adapts an EvictCallback to an EvictReasonCallback
+func withoutReason(onEvict EvictCallback) EvictReasonCallback {
+	if onEvict == nil {
+		return nil
+	}
+	return func(key interface{}, value interface{}, reason EvictReason) {
+		onEvict(key, value)
+	}
+}
+
+// Options is used to construct an LRU
+type Options struct {
+	// Size is the max number of entries, 0 means unbounded if MaxCost is set
+	Size int
+	// MaxCost is the max total cost of entries, 0 means unbounded
+	MaxCost int64
+	// Expire is the default expiration of entries, 0 means no expiration
+	Expire time.Duration
+	// Cost defaults to DefaultCost
+	Cost    CostFunc
+	OnEvict EvictReasonCallback
+	// Policy is used by New, it defaults to PolicyLRU
+	Policy Policy
+}
+
+// CostFunc is used to get the cost of an entry counted against the max cost of the cache
+type CostFunc func(key interface{}, value interface{}) int64
+
+// DefaultCost is the length of []byte and string values, other values cost 1
+func DefaultCost(key interface{}, value interface{}) int64 {
+	switch v := value.(type) {
+	case []byte:
+		return int64(len(v))
+	case string:
+		return int64(len(v))
+	default:
+		return 1
+	}
+}
+
+// LRU implements a non-thread safe LRU cache bounded by size and optionally by cost
 type LRU struct {
 	size      int
+	maxCost   int64
+	cost      int64
+	costFunc  CostFunc
 	evictList *list.List
 	items     map[interface{}]*list.Element
-	onEvict   EvictCallback
+	onEvict   EvictReasonCallback
 	expire    time.Duration
+	// expiring holds the entries have an expiration, the soonest to expire first
+	expiring expiryHeap
 }
 
 // entry is used to hold a value in the evictList
 type entry struct {
-	key    interface{}
-	value  interface{}
-	expire *time.Time
+	key        interface{}
+	value      interface{}
+	cost       int64
+	expire     *time.Time
+	softExpire *time.Time
+	// index is the position of the entry in the expiry heap of its list, -1 if it is not in the heap
+	index int
+}
+
+// expiryHeap is a min-heap of entries by their expiration
+type expiryHeap []*entry
+
+func (_this expiryHeap) Len() int { return len(_this) }
+
+func (_this expiryHeap) Less(i, j int) bool { return _this[i].expire.Before(*_this[j].expire) }
+
+func (_this expiryHeap) Swap(i, j int) {
+	_this[i], _this[j] = _this[j], _this[i]
+	_this[i].index = i
+	_this[j].index = j
+}
+
+func (_this *expiryHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.index = len(*_this)
+	*_this = append(*_this, e)
+}
+
+// expired counts the entries have expired in the subtree at i, subtrees of an entry not expired are skipped
+// since their entries expire later
+func (_this expiryHeap) expired(now time.Time, i int) int {
+	if i >= len(_this) || !now.After(*_this[i].expire) {
+		return 0
+	}
+	return 1 + _this.expired(now, 2*i+1) + _this.expired(now, 2*i+2)
+}
+
+func (_this *expiryHeap) Pop() interface{} {
+	old := *_this
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*_this = old[:len(old)-1]
+	return e
+}
+
+// Entry is a key value pair of the cache with its expirations, a zero time means no expiration
+type Entry struct {
+	Key        interface{}
+	Value      interface{}
+	Expire     time.Time
+	SoftExpire time.Time
+}
+
+func (_this *entry) export() Entry {
+	e := Entry{Key: _this.key, Value: _this.value}
+	if _this.expire != nil {
+		e.Expire = *_this.expire
+	}
+	if _this.softExpire != nil {
+		e.SoftExpire = *_this.softExpire
+	}
+	return e
 }
 
 // New constructs an LRU of the given size
@@ -73,12 +228,47 @@ func NewLRUWithExpire(size int, expire time.Duration, onEvict EvictCallback) (*L
 	if size <= 0 {
 		return nil, errors.New("must provide a positive size")
 	}
+	return NewLRUWithOptions(Options{
+		Size:    size,
+		Expire:  expire,
+		OnEvict: withoutReason(onEvict),
+	})
+}
+
+// NewLRUWithCost constructs an LRU evicts entries when there are more than size entries or their total cost
+// exceeds maxCost, size 0 means the number of entries is unbounded. costFunc defaults to DefaultCost
+func NewLRUWithCost(size int, maxCost int64, expire time.Duration, costFunc CostFunc, onEvict EvictCallback) (*LRU, error) {
+	if size < 0 || maxCost <= 0 {
+		return nil, errors.New("must provide a positive max cost and a non negative size")
+	}
+	return NewLRUWithOptions(Options{
+		Size:    size,
+		MaxCost: maxCost,
+		Expire:  expire,
+		Cost:    costFunc,
+		OnEvict: withoutReason(onEvict),
+	})
+}
+
+// NewLRUWithOptions constructs an LRU with options
+func NewLRUWithOptions(options Options) (*LRU, error) {
+	if options.Size < 0 || options.MaxCost < 0 {
+		return nil, errors.New("must provide a non negative size and max cost")
+	}
+	if options.Size == 0 && options.MaxCost == 0 {
+		return nil, errors.New("must provide a positive size or max cost")
+	}
+	if options.Cost == nil {
+		options.Cost = DefaultCost
+	}
 	c := &LRU{
-		size:      size,
+		size:      options.Size,
+		maxCost:   options.MaxCost,
+		costFunc:  options.Cost,
 		evictList: list.New(),
 		items:     make(map[interface{}]*list.Element),
-		onEvict:   onEvict,
-		expire:    expire,
+		onEvict:   options.OnEvict,
+		expire:    options.Expire,
 	}
 	return c, nil
 }
@@ -91,20 +281,41 @@ func (_this *entry) IsExpire() bool {
 	return time.Now().After(*_this.expire)
 }
 
+// IsStale is used to check soft expiration of cache, a stale entry is still served until it expires
+func (_this *entry) IsStale() bool {
+	if _this.softExpire == nil {
+		return false
+	}
+	return time.Now().After(*_this.softExpire)
+}
+
 // Purge is used to completely clear the cache.
 func (_this *LRU) Purge() {
 	for k, v := range _this.items {
 		if _this.onEvict != nil {
-			_this.onEvict(k, v.Value.(*entry).value)
+			_this.onEvict(k, v.Value.(*entry).value, EvictReasonPurged)
 		}
 		delete(_this.items, k)
 	}
 	_this.evictList.Init()
+	_this.cost = 0
+	_this.expiring = nil
 }
 
 // Add add a value to the cache with optional expiration. Returns true if an eviction occurred.
 func (_this *LRU) Add(key, value interface{}, expiration time.Duration) (evicted bool) {
-	var ex *time.Time = nil
+	return _this.AddWithSoftExpiration(key, value, 0, expiration)
+}
+
+// AddWithSoftExpiration add a value to the cache with optional soft expiration and expiration.
+// Returns true if an eviction occurred.
+func (_this *LRU) AddWithSoftExpiration(key, value interface{}, softExpiration, expiration time.Duration) (evicted bool) {
+	return _this.put(_this.newEntry(key, value, softExpiration, expiration))
+}
+
+// newEntry builds an entry expires after expiration or the default expiration
+func (_this *LRU) newEntry(key, value interface{}, softExpiration, expiration time.Duration) *entry {
+	var ex, softEx *time.Time
 
 	if expiration > 0 {
 		expire := time.Now().Add(expiration)
@@ -114,31 +325,111 @@ func (_this *LRU) Add(key, value interface{}, expiration time.Duration) (evicted
 		ex = &expire
 	}
 
+	if softExpiration > 0 {
+		softExpire := time.Now().Add(softExpiration)
+		softEx = &softExpire
+	}
+
+	return &entry{key: key, value: value, cost: _this.costFunc(key, value), expire: ex, softExpire: softEx, index: -1}
+}
+
+// put adds e as the most recently used entry or replaces the existing entry of its key,
+// returns true if an eviction occurred
+func (_this *LRU) put(e *entry) bool {
 	// Check for existing item
-	if ent, ok := _this.items[key]; ok {
+	if ent, ok := _this.items[e.key]; ok {
 		_this.evictList.MoveToFront(ent)
-		ent.Value.(*entry).value = value
-		ent.Value.(*entry).expire = ex
-		return false
+		kv := ent.Value.(*entry)
+		_this.cost += e.cost - kv.cost
+		kv.value = e.value
+		kv.cost = e.cost
+		kv.softExpire = e.softExpire
+		_this.setExpire(kv, e.expire)
+		return _this.evict()
 	}
 
 	// Add new item
-	ent := &entry{key: key, value: value, expire: ex}
-	entry := _this.evictList.PushFront(ent)
-	_this.items[key] = entry
+	entry := _this.evictList.PushFront(e)
+	_this.items[e.key] = entry
+	_this.cost += e.cost
+	e.index = -1
+	_this.setExpire(e, e.expire)
+
+	return _this.evict()
+}
+
+// peekEntry returns the entry of key without updating the recent-ness, expired entries are returned as well
+func (_this *LRU) peekEntry(key interface{}) (*entry, bool) {
+	if ent, ok := _this.items[key]; ok {
+		return ent.Value.(*entry), true
+	}
+	return nil, false
+}
+
+// take removes the entry of key without calling the eviction callback, it is used to move entries between lists
+func (_this *LRU) take(key interface{}) (*entry, bool) {
+	if ent, ok := _this.items[key]; ok {
+		return _this.detach(ent), true
+	}
+	return nil, false
+}
+
+// takeOldest removes the oldest entry without calling the eviction callback
+func (_this *LRU) takeOldest() (*entry, bool) {
+	if ent := _this.evictList.Back(); ent != nil {
+		return _this.detach(ent), true
+	}
+	return nil, false
+}
 
-	evict := _this.evictList.Len() > _this.size
-	// Verify size not exceeded
-	if evict {
+// oldestEntry returns the oldest entry without updating the recent-ness, expired entries are returned as well
+func (_this *LRU) oldestEntry() (*entry, bool) {
+	if ent := _this.evictList.Back(); ent != nil {
+		return ent.Value.(*entry), true
+	}
+	return nil, false
+}
+
+// removeKey removes the entry of key and calls the eviction callback with reason
+func (_this *LRU) removeKey(key interface{}, reason EvictReason) {
+	if ent, ok := _this.items[key]; ok {
+		_this.removeElement(ent, reason)
+	}
+}
+
+// len returns the number of entries including expired ones in constant time
+func (_this *LRU) len() int {
+	return _this.evictList.Len()
+}
+
+// setExpire changes the expiration of e and keeps the expiry heap in order
+func (_this *LRU) setExpire(e *entry, expire *time.Time) {
+	e.expire = expire
+	switch {
+	case expire == nil && e.index >= 0:
+		heap.Remove(&_this.expiring, e.index)
+	case expire != nil && e.index >= 0:
+		heap.Fix(&_this.expiring, e.index)
+	case expire != nil:
+		heap.Push(&_this.expiring, e)
+	}
+}
+
+// evict removes the oldest items until size and cost are not exceeded, an item costs more than
+// the max cost on its own is evicted as well. Returns true if an eviction occurred.
+func (_this *LRU) evict() (evicted bool) {
+	for (_this.size > 0 && _this.evictList.Len() > _this.size) || (_this.maxCost > 0 && _this.cost > _this.maxCost) {
 		_this.removeOldest()
+		evicted = true
 	}
-	return evict
+	return
 }
 
 // Get looks up a key's value from the cache.
 func (_this *LRU) Get(key interface{}) (value interface{}, ok bool) {
 	if ent, ok := _this.items[key]; ok {
 		if ent.Value.(*entry).IsExpire() {
+			_this.removeElement(ent, EvictReasonExpired)
 			return nil, false
 		}
 		_this.evictList.MoveToFront(ent)
@@ -150,6 +441,20 @@ func (_this *LRU) Get(key interface{}) (value interface{}, ok bool) {
 	return
 }
 
+// GetWithStale looks up a key's value from the cache and reports whether it has passed its soft expiration.
+func (_this *LRU) GetWithStale(key interface{}) (value interface{}, stale, ok bool) {
+	if ent, ok := _this.items[key]; ok {
+		kv := ent.Value.(*entry)
+		if kv.IsExpire() {
+			_this.removeElement(ent, EvictReasonExpired)
+			return nil, false, false
+		}
+		_this.evictList.MoveToFront(ent)
+		return kv.value, kv.IsStale(), true
+	}
+	return
+}
+
 // Contains checks if a key is in the cache, without updating the recent-ness
 // or deleting it for being stale.
 func (_this *LRU) Contains(key interface{}) (ok bool) {
@@ -179,25 +484,27 @@ func (_this *LRU) Peek(key interface{}) (value interface{}, ok bool) {
 // key was contained.
 func (_this *LRU) Remove(key interface{}) (present bool) {
 	if ent, ok := _this.items[key]; ok {
-		_this.removeElement(ent)
+		_this.removeElement(ent, EvictReasonRemoved)
 		return true
 	}
 	return false
 }
 
-// RemoveOldest removes the oldest item from the cache.
+// RemoveOldest removes the oldest item from the cache, expired items are skipped and removed.
 func (_this *LRU) RemoveOldest() (key, value interface{}, ok bool) {
+	_this.removeExpiredOldest()
 	ent := _this.evictList.Back()
 	if ent != nil {
-		_this.removeElement(ent)
+		_this.removeElement(ent, EvictReasonRemoved)
 		kv := ent.Value.(*entry)
 		return kv.key, kv.value, true
 	}
 	return nil, nil, false
 }
 
-// GetOldest returns the oldest entry
+// GetOldest returns the oldest entry, expired items are skipped and removed.
 func (_this *LRU) GetOldest() (key, value interface{}, ok bool) {
+	_this.removeExpiredOldest()
 	ent := _this.evictList.Back()
 	if ent != nil {
 		kv := ent.Value.(*entry)
@@ -206,20 +513,58 @@ func (_this *LRU) GetOldest() (key, value interface{}, ok bool) {
 	return nil, nil, false
 }
 
-// Keys returns a slice of the keys in the cache, from oldest to newest.
+// Keys returns a slice of the keys in the cache which are not expired, from oldest to newest.
 func (_this *LRU) Keys() []interface{} {
-	keys := make([]interface{}, len(_this.items))
-	i := 0
+	keys := make([]interface{}, 0, len(_this.items))
 	for ent := _this.evictList.Back(); ent != nil; ent = ent.Prev() {
-		keys[i] = ent.Value.(*entry).key
-		i++
+		kv := ent.Value.(*entry)
+		if kv.IsExpire() {
+			continue
+		}
+		keys = append(keys, kv.key)
 	}
 	return keys
 }
 
-// Len returns the number of items in the cache.
+// Entries returns the entries in the cache which are not expired, from oldest to newest.
+func (_this *LRU) Entries() []Entry {
+	entries := make([]Entry, 0, len(_this.items))
+	for ent := _this.evictList.Back(); ent != nil; ent = ent.Prev() {
+		kv := ent.Value.(*entry)
+		if kv.IsExpire() {
+			continue
+		}
+		entries = append(entries, kv.export())
+	}
+	return entries
+}
+
+// Len returns the number of items in the cache which are not expired, it only visits the expired items
+// in the expiry heap so it runs in constant time unless expired items are waiting to be removed
 func (_this *LRU) Len() int {
-	return _this.evictList.Len()
+	if len(_this.expiring) == 0 {
+		return _this.evictList.Len()
+	}
+	return _this.evictList.Len() - _this.expiring.expired(time.Now(), 0)
+}
+
+// RemoveExpired removes every expired item from the cache, the soonest to expire first.
+func (_this *LRU) RemoveExpired() (removed int) {
+	if len(_this.expiring) == 0 {
+		return 0
+	}
+
+	now := time.Now()
+	for len(_this.expiring) > 0 && now.After(*_this.expiring[0].expire) {
+		_this.removeElement(_this.items[_this.expiring[0].key], EvictReasonExpired)
+		removed++
+	}
+	return
+}
+
+// Cost returns the total cost of entries in the cache.
+func (_this *LRU) Cost() int64 {
+	return _this.cost
 }
 
 // Resize changes the cache size.
@@ -239,16 +584,37 @@ func (_this *LRU) Resize(size int) (evicted int) {
 func (_this *LRU) removeOldest() {
 	ent := _this.evictList.Back()
 	if ent != nil {
-		_this.removeElement(ent)
+		reason := EvictReasonCapacity
+		if ent.Value.(*entry).IsExpire() {
+			reason = EvictReasonExpired
+		}
+		_this.removeElement(ent, reason)
+	}
+}
+
+// removeExpiredOldest removes the oldest items as long as they are expired
+func (_this *LRU) removeExpiredOldest() {
+	for ent := _this.evictList.Back(); ent != nil && ent.Value.(*entry).IsExpire(); ent = _this.evictList.Back() {
+		_this.removeElement(ent, EvictReasonExpired)
 	}
 }
 
 // removeElement is used to remove a given list element from the cache
-func (_this *LRU) removeElement(e *list.Element) {
+func (_this *LRU) removeElement(e *list.Element, reason EvictReason) {
+	kv := _this.detach(e)
+	if _this.onEvict != nil {
+		_this.onEvict(kv.key, kv.value, reason)
+	}
+}
+
+// detach removes a given list element from the cache without calling the eviction callback
+func (_this *LRU) detach(e *list.Element) *entry {
 	_this.evictList.Remove(e)
 	kv := e.Value.(*entry)
 	delete(_this.items, kv.key)
-	if _this.onEvict != nil {
-		_this.onEvict(kv.key, kv.value)
+	_this.cost -= kv.cost
+	if kv.index >= 0 {
+		heap.Remove(&_this.expiring, kv.index)
 	}
+	return kv
 }
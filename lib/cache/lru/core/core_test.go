@@ -2,6 +2,7 @@ package core
 
 import (
 	"testing"
+	"time"
 )
 
 func TestLRU(t *testing.T) {
@@ -206,3 +207,209 @@ func TestLRU_Resize(t *testing.T) {
 		t.Errorf("Cache should have contained 2 elements")
 	}
 }
+
+// Test that a stale entry is served until it expires
+func TestLRU_SoftExpiration(t *testing.T) {
+	l, err := NewLRU(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.AddWithSoftExpiration(1, 1, 10*time.Millisecond, 50*time.Millisecond)
+	if v, stale, ok := l.GetWithStale(1); !ok || stale || v != 1 {
+		t.Errorf("1 should be fresh: %v, %v, %v", v, stale, ok)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if v, stale, ok := l.GetWithStale(1); !ok || !stale || v != 1 {
+		t.Errorf("1 should be stale: %v, %v, %v", v, stale, ok)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if _, _, ok := l.GetWithStale(1); ok {
+		t.Errorf("1 should be expired")
+	}
+}
+
+// Test that entries are evicted when their total cost exceeds the max cost
+func TestLRU_Cost(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k interface{}, v interface{}) {
+		evictCounter++
+	}
+	l, err := NewLRUWithCost(0, 10, 0, nil, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, []byte("aaaa"), 0)
+	l.Add(2, []byte("bbbb"), 0)
+	if l.Cost() != 8 {
+		t.Errorf("bad cost: %v", l.Cost())
+	}
+
+	if !l.Add(3, []byte("cccc"), 0) {
+		t.Errorf("should have an eviction")
+	}
+	if l.Contains(1) || l.Cost() != 8 || evictCounter != 1 {
+		t.Errorf("1 should have been evicted: %v, %v", l.Cost(), evictCounter)
+	}
+
+	// Growing an existing entry evicts the oldest ones
+	if !l.Add(3, []byte("cccccccc"), 0) {
+		t.Errorf("should have an eviction")
+	}
+	if l.Contains(2) || !l.Contains(3) || l.Cost() != 8 {
+		t.Errorf("2 should have been evicted: %v", l.Cost())
+	}
+
+	l.Remove(3)
+	if l.Cost() != 0 {
+		t.Errorf("bad cost: %v", l.Cost())
+	}
+
+	// An entry costs more than the max cost is not kept
+	l.Add(4, []byte("ddddddddddd"), 0)
+	if l.Contains(4) || l.Len() != 0 || l.Cost() != 0 {
+		t.Errorf("4 should have been evicted: %v", l.Cost())
+	}
+
+	l.Add(5, "eeee", 0)
+	l.Purge()
+	if l.Cost() != 0 {
+		t.Errorf("bad cost: %v", l.Cost())
+	}
+
+	if _, err := NewLRUWithCost(1, 0, 0, nil, nil); err == nil {
+		t.Errorf("should have an error with max cost 0")
+	}
+}
+
+// Test that both size and cost bound the cache
+func TestLRU_CostAndSize(t *testing.T) {
+	l, err := NewLRUWithCost(2, 100, 0, func(key interface{}, value interface{}) int64 {
+		return int64(value.(int))
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 10, 0)
+	l.Add(2, 10, 0)
+	l.Add(3, 10, 0)
+	if l.Len() != 2 || l.Cost() != 20 {
+		t.Errorf("bad len or cost: %v, %v", l.Len(), l.Cost())
+	}
+
+	l.Add(4, 91, 0)
+	if l.Len() != 1 || l.Cost() != 91 || !l.Contains(4) {
+		t.Errorf("bad len or cost: %v, %v", l.Len(), l.Cost())
+	}
+}
+
+// Test that expired entries are skipped, removed and reported with their reason
+func TestLRU_Expiration(t *testing.T) {
+	reasons := make(map[interface{}]EvictReason)
+	l, err := NewLRUWithOptions(Options{
+		Size: 3,
+		OnEvict: func(key interface{}, value interface{}, reason EvictReason) {
+			reasons[key] = reason
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1, 10*time.Millisecond)
+	l.Add(2, 2, 10*time.Millisecond)
+	l.Add(3, 3, 0)
+	if l.Len() != 3 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if l.Len() != 1 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+	if keys := l.Keys(); len(keys) != 1 || keys[0] != 3 {
+		t.Errorf("bad keys: %v", keys)
+	}
+
+	if k, _, ok := l.GetOldest(); !ok || k != 3 {
+		t.Errorf("3 should be the oldest: %v", k)
+	}
+	if len(reasons) != 2 || reasons[1] != EvictReasonExpired || reasons[2] != EvictReasonExpired {
+		t.Errorf("1 and 2 should have been expired: %v", reasons)
+	}
+
+	l.Add(4, 4, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if removed := l.RemoveExpired(); removed != 1 {
+		t.Errorf("1 element should have been removed: %v", removed)
+	}
+	if _, ok := reasons[4]; !ok || reasons[4] != EvictReasonExpired || l.Len() != 1 {
+		t.Errorf("4 should have been expired: %v", reasons)
+	}
+
+	l.Add(5, 5, 0)
+	l.Add(6, 6, 0)
+	l.Add(7, 7, 0)
+	l.Remove(6)
+	l.Purge()
+	if reasons[3] != EvictReasonCapacity || reasons[6] != EvictReasonRemoved || reasons[5] != EvictReasonPurged {
+		t.Errorf("bad reasons: %v", reasons)
+	}
+	if EvictReasonCapacity.String() != "capacity" {
+		t.Errorf("bad reason name: %v", EvictReasonCapacity)
+	}
+}
+
+func TestLRU_Entries(t *testing.T) {
+	l, err := NewLRU(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1, time.Hour)
+	l.AddWithSoftExpiration(2, 2, time.Minute, 0)
+	l.Add(3, 3, time.Nanosecond)
+	l.Get(1)
+
+	entries := l.Entries()
+	if len(entries) != 2 || entries[0].Key != 2 || entries[1].Key != 1 {
+		t.Fatalf("bad entries: %v", entries)
+	}
+	if !entries[0].Expire.IsZero() || entries[0].SoftExpire.IsZero() {
+		t.Errorf("2 should only have a soft expiration: %v", entries[0])
+	}
+	if entries[1].Expire.IsZero() || !entries[1].SoftExpire.IsZero() {
+		t.Errorf("1 should only have an expiration: %v", entries[1])
+	}
+}
+
+func TestLRU_ExpiryHeap(t *testing.T) {
+	l, err := NewLRU(100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		l.Add(i, i, time.Duration(50-i)*time.Millisecond+10*time.Millisecond)
+	}
+	l.Add(100, 100, 0)
+	// Updates move entries in and out of the heap
+	l.Add(0, 0, 0)
+	l.Add(1, 1, time.Hour)
+	l.Remove(2)
+
+	time.Sleep(100 * time.Millisecond)
+	if l.Len() != 3 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+	if removed := l.RemoveExpired(); removed != 47 {
+		t.Errorf("bad removed: %v", removed)
+	}
+	if l.Len() != 3 || l.evictList.Len() != 3 || len(l.expiring) != 1 {
+		t.Errorf("bad len: %v", l.Len())
+	}
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// Policy decides which entry is evicted when the cache is full
+type Policy int
+
+const (
+	// PolicyLRU evicts the least recently used entry
+	PolicyLRU Policy = iota
+	// Policy2Q keeps entries used once apart from entries used repeatedly so scans do not flush the hot set
+	Policy2Q
+	// PolicyARC balances recency and frequency adaptively
+	PolicyARC
+	// PolicyTinyLFU admits an entry to the main cache only if it is used more often than the entry it replaces
+	PolicyTinyLFU
+)
+
+func (_this Policy) String() string {
+	switch _this {
+	case PolicyLRU:
+		return "lru"
+	case Policy2Q:
+		return "2q"
+	case PolicyARC:
+		return "arc"
+	case PolicyTinyLFU:
+		return "tinylfu"
+	default:
+		return "unknown"
+	}
+}
+
+// New constructs a cache with the policy of options, max cost is only supported by PolicyLRU
+func New(options Options) (LRUCache, error) {
+	if options.Policy == PolicyLRU {
+		l, err := NewLRUWithOptions(options)
+		if err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	if options.MaxCost > 0 {
+		return nil, fmt.Errorf("max cost is not supported by policy %s", options.Policy)
+	}
+	if options.Size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+
+	switch options.Policy {
+	case Policy2Q:
+		return New2Q(options.Size, options.Expire, options.OnEvict), nil
+	case PolicyARC:
+		return NewARC(options.Size, options.Expire, options.OnEvict), nil
+	case PolicyTinyLFU:
+		return NewTinyLFU(options.Size, options.Expire, options.OnEvict), nil
+	default:
+		return nil, fmt.Errorf("policy %d is not supported", options.Policy)
+	}
+}
+
+// newList constructs an LRU is used as a list by other policies, a list without onEvict keeps keys only
+func newList(size int, expire time.Duration, onEvict EvictReasonCallback) *LRU {
+	if size <= 0 {
+		size = 1
+	}
+	l, _ := NewLRUWithOptions(Options{
+		Size:    size,
+		Expire:  expire,
+		OnEvict: onEvict,
+	})
+	return l
+}
+
+// evictEntry calls onEvict for an entry taken out of a list because of capacity
+func evictEntry(onEvict EvictReasonCallback, e *entry) {
+	if onEvict == nil {
+		return
+	}
+	reason := EvictReasonCapacity
+	if e.IsExpire() {
+		reason = EvictReasonExpired
+	}
+	onEvict(e.key, e.value, reason)
+}
+
+// ghost returns an entry holds the key of e only
+func ghost(e *entry) *entry {
+	return &entry{key: e.key}
+}
+
+// getFromList gets key from l, expired entries are removed
+func getFromList(l *LRU, key interface{}) (*entry, bool) {
+	e, ok := l.peekEntry(key)
+	if !ok {
+		return nil, false
+	}
+	if e.IsExpire() {
+		l.removeKey(key, EvictReasonExpired)
+		return nil, false
+	}
+	return e, true
+}
+
+// HashKey hashes keys of common types without reflection, other keys are hashed by their formatted value
+func HashKey(key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		return hashString(k)
+	case []byte:
+		h := fnv.New64a()
+		_, _ = h.Write(k)
+		return h.Sum64()
+	case int:
+		return mix(uint64(k))
+	case int32:
+		return mix(uint64(k))
+	case int64:
+		return mix(uint64(k))
+	case uint:
+		return mix(uint64(k))
+	case uint32:
+		return mix(uint64(k))
+	case uint64:
+		return mix(k)
+	default:
+		h := fnv.New64a()
+		_, _ = fmt.Fprintf(h, "%T:%v", key, key)
+		return h.Sum64()
+	}
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// hashString is FNV-1a of s without allocating
+func hashString(s string) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// mix is the finalizer of splitmix64
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
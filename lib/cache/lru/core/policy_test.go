@@ -0,0 +1,209 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+var policies = []Policy{PolicyLRU, Policy2Q, PolicyARC, PolicyTinyLFU}
+
+// Test that every policy behaves like a cache of the given size
+func TestPolicies(t *testing.T) {
+	for _, policy := range policies {
+		t.Run(policy.String(), func(t *testing.T) {
+			reasons := make(map[interface{}]EvictReason)
+			l, err := New(Options{
+				Size:   128,
+				Policy: policy,
+				OnEvict: func(key interface{}, value interface{}, reason EvictReason) {
+					if key != value {
+						t.Errorf("Evict values not equal (%v!=%v)", key, value)
+					}
+					reasons[key] = reason
+				},
+			})
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			for i := 0; i < 256; i++ {
+				l.Add(i, i, 0)
+			}
+			if l.Len() != 128 {
+				t.Fatalf("bad len: %v", l.Len())
+			}
+			if len(l.Keys()) != 128 {
+				t.Fatalf("bad keys: %v", len(l.Keys()))
+			}
+
+			evicted := 0
+			for _, reason := range reasons {
+				if reason != EvictReasonCapacity {
+					t.Fatalf("bad reason: %v", reason)
+				}
+				evicted++
+			}
+			if evicted != 128 {
+				t.Fatalf("bad evicted: %v", evicted)
+			}
+
+			for _, k := range l.Keys() {
+				if v, ok := l.Get(k); !ok || v != k {
+					t.Fatalf("bad key: %v", k)
+				}
+				if !l.Contains(k) {
+					t.Fatalf("should contain %v", k)
+				}
+				if v, ok := l.Peek(k); !ok || v != k {
+					t.Fatalf("bad peek: %v", k)
+				}
+			}
+
+			// Updating a key keeps a single entry
+			k := l.Keys()[0]
+			l.Add(k, k, 0)
+			if l.Len() != 128 {
+				t.Fatalf("bad len: %v", l.Len())
+			}
+
+			if !l.Remove(k) || l.Contains(k) || reasons[k] != EvictReasonRemoved {
+				t.Fatalf("%v should have been removed", k)
+			}
+
+			if k, v, ok := l.RemoveOldest(); !ok || k != v || l.Contains(k) {
+				t.Fatalf("bad oldest: %v", k)
+			}
+
+			l.Add(-1, -1, 10*time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+			if _, ok := l.Get(-1); ok {
+				t.Fatalf("-1 should be expired")
+			}
+			if reason, ok := reasons[-1]; !ok || reason != EvictReasonExpired {
+				t.Fatalf("-1 should have been expired: %v", reason)
+			}
+
+			l.Add(-2, -2, 10*time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+			if l.Len() != 126 || l.RemoveExpired() != 1 {
+				t.Fatalf("-2 should have been removed")
+			}
+
+			if evicted := l.Resize(64); evicted != 62 || l.Len() != 64 {
+				t.Fatalf("bad resize: %v, %v", evicted, l.Len())
+			}
+
+			l.AddWithSoftExpiration("soft", "soft", time.Millisecond, 0)
+			time.Sleep(5 * time.Millisecond)
+			if v, stale, ok := l.GetWithStale("soft"); !ok || !stale || v != "soft" {
+				t.Fatalf("soft should be stale: %v, %v, %v", v, stale, ok)
+			}
+
+			l.Purge()
+			if l.Len() != 0 || l.Cost() != 0 || reasons["soft"] != EvictReasonPurged {
+				t.Fatalf("bad purge: %v", l.Len())
+			}
+		})
+	}
+
+	if _, err := New(Options{Size: 1, MaxCost: 1, Policy: PolicyARC}); err == nil {
+		t.Errorf("max cost should not be supported by arc")
+	}
+}
+
+// zipfTrace returns keys drawn from a zipf distribution over n keys
+func zipfTrace(n, length int) []int {
+	r := rand.New(rand.NewSource(1))
+	z := rand.NewZipf(r, 1.01, 1, uint64(n-1))
+	trace := make([]int, length)
+	for i := range trace {
+		trace[i] = int(z.Uint64())
+	}
+	return trace
+}
+
+// scanTrace mixes a zipf trace with long scans over keys are never read again
+func scanTrace(n, length int) []int {
+	trace := zipfTrace(n, length)
+	next := n
+	for i := 0; i+2000 < len(trace); i += 10000 {
+		for j := 0; j < 2000; j++ {
+			trace[i+j] = next
+			next++
+		}
+	}
+	return trace
+}
+
+func hitRatio(t testing.TB, policy Policy, size int, trace []int) float64 {
+	l, err := New(Options{Size: size, Policy: policy})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	hits := 0
+	for _, k := range trace {
+		if _, ok := l.Get(k); ok {
+			hits++
+			continue
+		}
+		l.Add(k, k, 0)
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+// Test that scans do not flush the hot set out of scan resistant policies
+func TestPolicies_ScanResistance(t *testing.T) {
+	trace := scanTrace(10000, 200000)
+	lru := hitRatio(t, PolicyLRU, 1000, trace)
+
+	for _, policy := range []Policy{Policy2Q, PolicyARC, PolicyTinyLFU} {
+		if ratio := hitRatio(t, policy, 1000, trace); ratio <= lru {
+			t.Errorf("%s should have a higher hit ratio than lru: %.4f <= %.4f", policy, ratio, lru)
+		}
+	}
+}
+
+func benchmarkPolicy(b *testing.B, policy Policy, trace []int) {
+	for i := 0; i < b.N; i++ {
+		b.ReportMetric(hitRatio(b, policy, 1000, trace)*100, "hit%")
+	}
+}
+
+func BenchmarkPolicies_Zipf(b *testing.B) {
+	trace := zipfTrace(100000, 200000)
+	for _, policy := range policies {
+		b.Run(policy.String(), func(b *testing.B) {
+			benchmarkPolicy(b, policy, trace)
+		})
+	}
+}
+
+func BenchmarkPolicies_ZipfWithScans(b *testing.B) {
+	trace := scanTrace(100000, 200000)
+	for _, policy := range policies {
+		b.Run(policy.String(), func(b *testing.B) {
+			benchmarkPolicy(b, policy, trace)
+		})
+	}
+}
+
+func BenchmarkPolicies_Get(b *testing.B) {
+	trace := zipfTrace(100000, 1<<16)
+	for _, policy := range policies {
+		b.Run(policy.String(), func(b *testing.B) {
+			l, err := New(Options{Size: 8192, Policy: policy})
+			if err != nil {
+				b.Fatalf("err: %v", err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				k := trace[i&(len(trace)-1)]
+				if _, ok := l.Get(k); !ok {
+					l.Add(k, k, 0)
+				}
+			}
+		})
+	}
+}
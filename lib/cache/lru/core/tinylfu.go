@@ -0,0 +1,337 @@
+package core
+
+import (
+	"time"
+)
+
+const (
+	// DefaultTinyLFUWindowRatio is the share of the cache for the admission window
+	DefaultTinyLFUWindowRatio = 0.01
+	// DefaultTinyLFUProtectedRatio is the share of the main cache for entries used repeatedly
+	DefaultTinyLFUProtectedRatio = 0.80
+	// DefaultTinyLFUSampleRatio is the number of accesses, as a multiple of the size, after which frequencies are halved
+	DefaultTinyLFUSampleRatio = 10
+)
+
+// TinyLFU implements a non-thread safe fixed size W-TinyLFU cache, new entries go to a small LRU window and
+// compete with the oldest entry of the main segmented LRU when they leave it, the entry accessed more often
+// according to a count-min sketch is kept
+type TinyLFU struct {
+	size          int
+	windowSize    int
+	protectedSize int
+
+	window    *LRU
+	probation *LRU
+	protected *LRU
+
+	sketch *countMinSketch
+
+	onEvict EvictReasonCallback
+}
+
+// NewTinyLFU constructs a W-TinyLFU cache of the given size with default expire
+func NewTinyLFU(size int, expire time.Duration, onEvict EvictReasonCallback) *TinyLFU {
+	c := &TinyLFU{
+		window:    newList(size, expire, onEvict),
+		probation: newList(size, expire, onEvict),
+		protected: newList(size, expire, onEvict),
+		onEvict:   onEvict,
+	}
+	c.setSize(size)
+	return c
+}
+
+func (_this *TinyLFU) setSize(size int) {
+	_this.size = size
+	_this.windowSize = int(float64(size) * DefaultTinyLFUWindowRatio)
+	if _this.windowSize < 1 {
+		_this.windowSize = 1
+	}
+	_this.protectedSize = int(float64(_this.mainSize()) * DefaultTinyLFUProtectedRatio)
+	_this.sketch = newCountMinSketch(size)
+
+	for _, l := range []*LRU{_this.window, _this.probation, _this.protected} {
+		l.Resize(size)
+	}
+}
+
+func (_this *TinyLFU) mainSize() int {
+	return _this.size - _this.windowSize
+}
+
+func (_this *TinyLFU) lists() []*LRU {
+	return []*LRU{_this.window, _this.probation, _this.protected}
+}
+
+func (_this *TinyLFU) Add(key, value interface{}, expiration time.Duration) bool {
+	return _this.AddWithSoftExpiration(key, value, 0, expiration)
+}
+
+func (_this *TinyLFU) AddWithSoftExpiration(key, value interface{}, softExpiration, expiration time.Duration) bool {
+	_this.sketch.increment(key)
+	e := _this.window.newEntry(key, value, softExpiration, expiration)
+
+	for _, l := range _this.lists() {
+		if _, ok := l.peekEntry(key); ok {
+			l.put(e)
+			return false
+		}
+	}
+
+	_this.window.put(e)
+	if _this.window.len() <= _this.windowSize {
+		return false
+	}
+
+	candidate, _ := _this.window.takeOldest()
+	return _this.admit(candidate)
+}
+
+// admit moves a candidate leaving the window to the main cache if there is room or it is used more often than
+// the victim of the main cache, returns true if the candidate or the victim is evicted
+func (_this *TinyLFU) admit(candidate *entry) bool {
+	if _this.probation.len()+_this.protected.len() < _this.mainSize() {
+		_this.probation.put(candidate)
+		return false
+	}
+
+	victims := _this.probation
+	if victims.len() == 0 {
+		victims = _this.protected
+	}
+
+	victim, ok := victims.oldestEntry()
+	if !ok || candidate.IsExpire() || (!victim.IsExpire() && _this.sketch.estimate(candidate.key) <= _this.sketch.estimate(victim.key)) {
+		evictEntry(_this.onEvict, candidate)
+		return true
+	}
+
+	victims.takeOldest()
+	evictEntry(_this.onEvict, victim)
+	_this.probation.put(candidate)
+	return true
+}
+
+func (_this *TinyLFU) Get(key interface{}) (value interface{}, ok bool) {
+	value, _, ok = _this.GetWithStale(key)
+	return
+}
+
+func (_this *TinyLFU) GetWithStale(key interface{}) (value interface{}, stale, ok bool) {
+	_this.sketch.increment(key)
+
+	if value, stale, ok = _this.window.GetWithStale(key); ok {
+		return
+	}
+	if value, stale, ok = _this.protected.GetWithStale(key); ok {
+		return
+	}
+
+	// An entry on probation is used again, it is protected
+	if e, ok := getFromList(_this.probation, key); ok {
+		_this.probation.take(key)
+		_this.protected.put(e)
+		if _this.protected.len() > _this.protectedSize {
+			demoted, _ := _this.protected.takeOldest()
+			_this.probation.put(demoted)
+		}
+		return e.value, e.IsStale(), true
+	}
+	return nil, false, false
+}
+
+func (_this *TinyLFU) Contains(key interface{}) bool {
+	for _, l := range _this.lists() {
+		if l.Contains(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (_this *TinyLFU) Peek(key interface{}) (value interface{}, ok bool) {
+	for _, l := range _this.lists() {
+		if value, ok = l.Peek(key); ok {
+			return
+		}
+	}
+	return nil, false
+}
+
+func (_this *TinyLFU) Remove(key interface{}) bool {
+	for _, l := range _this.lists() {
+		if l.Remove(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// evictionOrder is probation, protected then window, it is the order entries are removed by RemoveOldest
+func (_this *TinyLFU) evictionOrder() []*LRU {
+	return []*LRU{_this.probation, _this.protected, _this.window}
+}
+
+func (_this *TinyLFU) RemoveOldest() (key, value interface{}, ok bool) {
+	for _, l := range _this.evictionOrder() {
+		if key, value, ok = l.RemoveOldest(); ok {
+			return
+		}
+	}
+	return nil, nil, false
+}
+
+func (_this *TinyLFU) GetOldest() (key, value interface{}, ok bool) {
+	for _, l := range _this.evictionOrder() {
+		if key, value, ok = l.GetOldest(); ok {
+			return
+		}
+	}
+	return nil, nil, false
+}
+
+// Keys returns keys of probation, protected then window, from oldest to newest in each list
+func (_this *TinyLFU) Keys() []interface{} {
+	var keys []interface{}
+	for _, l := range _this.evictionOrder() {
+		keys = append(keys, l.Keys()...)
+	}
+	return keys
+}
+
+// Entries returns entries of probation, protected then window, from oldest to newest in each list
+func (_this *TinyLFU) Entries() []Entry {
+	var entries []Entry
+	for _, l := range _this.evictionOrder() {
+		entries = append(entries, l.Entries()...)
+	}
+	return entries
+}
+
+func (_this *TinyLFU) Len() (length int) {
+	for _, l := range _this.lists() {
+		length += l.Len()
+	}
+	return
+}
+
+func (_this *TinyLFU) Purge() {
+	for _, l := range _this.lists() {
+		l.Purge()
+	}
+	_this.sketch.reset()
+}
+
+func (_this *TinyLFU) Resize(size int) (evicted int) {
+	for _, l := range _this.evictionOrder() {
+		for _this.window.len()+_this.probation.len()+_this.protected.len() > size && l.len() > 0 {
+			l.removeOldest()
+			evicted++
+		}
+	}
+
+	_this.setSize(size)
+
+	for _this.window.len() > _this.windowSize {
+		candidate, _ := _this.window.takeOldest()
+		if _this.admit(candidate) {
+			evicted++
+		}
+	}
+	for _this.protected.len() > _this.protectedSize {
+		demoted, _ := _this.protected.takeOldest()
+		_this.probation.put(demoted)
+	}
+	return
+}
+
+func (_this *TinyLFU) Cost() (cost int64) {
+	for _, l := range _this.lists() {
+		cost += l.Cost()
+	}
+	return
+}
+
+func (_this *TinyLFU) RemoveExpired() (removed int) {
+	for _, l := range _this.lists() {
+		removed += l.RemoveExpired()
+	}
+	return
+}
+
+const sketchDepth = 4
+
+// countMinSketch estimates access frequencies with 4 bit counters, counters are halved every sample
+// accesses so old popularity fades out
+type countMinSketch struct {
+	counters  [sketchDepth][]uint8
+	mask      uint64
+	additions int
+	sample    int
+}
+
+// newCountMinSketch makes rows of 4 counters per entry of the cache so collisions do not outweigh real accesses
+func newCountMinSketch(size int) *countMinSketch {
+	width := 16
+	for width < 4*size {
+		width <<= 1
+	}
+
+	s := &countMinSketch{
+		mask:   uint64(width - 1),
+		sample: size * DefaultTinyLFUSampleRatio,
+	}
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (_this *countMinSketch) index(h uint64, i int) uint64 {
+	h1, h2 := h&0xffffffff, h>>32
+	return (h1 + uint64(i)*h2) & _this.mask
+}
+
+func (_this *countMinSketch) increment(key interface{}) {
+	h := HashKey(key)
+	for i := range _this.counters {
+		if idx := _this.index(h, i); _this.counters[i][idx] < 15 {
+			_this.counters[i][idx]++
+		}
+	}
+
+	_this.additions++
+	if _this.additions >= _this.sample {
+		_this.halve()
+	}
+}
+
+func (_this *countMinSketch) estimate(key interface{}) uint8 {
+	h := HashKey(key)
+	min := uint8(15)
+	for i := range _this.counters {
+		if c := _this.counters[i][_this.index(h, i)]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+func (_this *countMinSketch) halve() {
+	for i := range _this.counters {
+		for j := range _this.counters[i] {
+			_this.counters[i][j] >>= 1
+		}
+	}
+	_this.additions /= 2
+}
+
+func (_this *countMinSketch) reset() {
+	for i := range _this.counters {
+		for j := range _this.counters[i] {
+			_this.counters[i][j] = 0
+		}
+	}
+	_this.additions = 0
+}
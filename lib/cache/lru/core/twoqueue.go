@@ -0,0 +1,186 @@
+package core
+
+import (
+	"time"
+)
+
+const (
+	// Default2QRecentRatio is the share of the cache for entries used once
+	Default2QRecentRatio = 0.25
+	// Default2QGhostEntries is the number of evicted recent keys are remembered, as a share of the cache
+	Default2QGhostEntries = 0.50
+)
+
+// TwoQueue implements a non-thread safe fixed size 2Q cache, entries are added to the recent list
+// and promoted to the frequent list when they are used again, keys evicted from the recent list are
+// remembered so they go straight to the frequent list when they come back
+type TwoQueue struct {
+	size       int
+	recentSize int
+
+	recent      *LRU
+	frequent    *LRU
+	recentEvict *LRU
+
+	onEvict EvictReasonCallback
+}
+
+// New2Q constructs a 2Q cache of the given size with default expire
+func New2Q(size int, expire time.Duration, onEvict EvictReasonCallback) *TwoQueue {
+	c := &TwoQueue{
+		recent:      newList(size, expire, onEvict),
+		frequent:    newList(size, expire, onEvict),
+		recentEvict: newList(size, 0, nil),
+		onEvict:     onEvict,
+	}
+	c.setSize(size)
+	return c
+}
+
+func (_this *TwoQueue) setSize(size int) {
+	_this.size = size
+	_this.recentSize = int(float64(size) * Default2QRecentRatio)
+	_this.recent.Resize(size)
+	_this.frequent.Resize(size)
+	_this.recentEvict.Resize(int(float64(size)*Default2QGhostEntries) + 1)
+}
+
+func (_this *TwoQueue) Add(key, value interface{}, expiration time.Duration) bool {
+	return _this.AddWithSoftExpiration(key, value, 0, expiration)
+}
+
+func (_this *TwoQueue) AddWithSoftExpiration(key, value interface{}, softExpiration, expiration time.Duration) (evicted bool) {
+	e := _this.recent.newEntry(key, value, softExpiration, expiration)
+
+	if _, ok := _this.frequent.peekEntry(key); ok {
+		_this.frequent.put(e)
+		return false
+	}
+
+	// A recent entry is set again, it is used frequently
+	if _, ok := _this.recent.take(key); ok {
+		_this.frequent.put(e)
+		return false
+	}
+
+	if _, ok := _this.recentEvict.take(key); ok {
+		evicted = _this.ensureSpace(true)
+		_this.frequent.put(e)
+		return
+	}
+
+	evicted = _this.ensureSpace(false)
+	_this.recent.put(e)
+	return
+}
+
+// ensureSpace evicts an entry if the cache is full
+func (_this *TwoQueue) ensureSpace(recentEvict bool) bool {
+	if _this.recent.len()+_this.frequent.len() < _this.size {
+		return false
+	}
+	_this.evictOne(recentEvict)
+	return true
+}
+
+// evictOne evicts the oldest recent entry if the recent list is over its share, or the oldest frequent entry otherwise
+func (_this *TwoQueue) evictOne(recentEvict bool) {
+	recentLen := _this.recent.len()
+	if recentLen > 0 && (recentLen > _this.recentSize || (recentLen == _this.recentSize && !recentEvict) || _this.frequent.len() == 0) {
+		e, _ := _this.recent.takeOldest()
+		_this.recentEvict.put(ghost(e))
+		evictEntry(_this.onEvict, e)
+		return
+	}
+	_this.frequent.removeOldest()
+}
+
+func (_this *TwoQueue) Get(key interface{}) (value interface{}, ok bool) {
+	value, _, ok = _this.GetWithStale(key)
+	return
+}
+
+func (_this *TwoQueue) GetWithStale(key interface{}) (value interface{}, stale, ok bool) {
+	if value, stale, ok = _this.frequent.GetWithStale(key); ok {
+		return
+	}
+
+	if e, ok := getFromList(_this.recent, key); ok {
+		_this.recent.take(key)
+		_this.frequent.put(e)
+		return e.value, e.IsStale(), true
+	}
+	return nil, false, false
+}
+
+func (_this *TwoQueue) Contains(key interface{}) bool {
+	return _this.frequent.Contains(key) || _this.recent.Contains(key)
+}
+
+func (_this *TwoQueue) Peek(key interface{}) (value interface{}, ok bool) {
+	if value, ok = _this.frequent.Peek(key); ok {
+		return
+	}
+	return _this.recent.Peek(key)
+}
+
+func (_this *TwoQueue) Remove(key interface{}) bool {
+	_this.recentEvict.take(key)
+	if _this.frequent.Remove(key) {
+		return true
+	}
+	return _this.recent.Remove(key)
+}
+
+// RemoveOldest removes the oldest recent entry, or the oldest frequent entry if there is no recent entry
+func (_this *TwoQueue) RemoveOldest() (key, value interface{}, ok bool) {
+	if key, value, ok = _this.recent.RemoveOldest(); ok {
+		return
+	}
+	return _this.frequent.RemoveOldest()
+}
+
+// GetOldest returns the oldest recent entry, or the oldest frequent entry if there is no recent entry
+func (_this *TwoQueue) GetOldest() (key, value interface{}, ok bool) {
+	if key, value, ok = _this.recent.GetOldest(); ok {
+		return
+	}
+	return _this.frequent.GetOldest()
+}
+
+// Keys returns recent keys then frequent keys, from oldest to newest in each list
+func (_this *TwoQueue) Keys() []interface{} {
+	return append(_this.recent.Keys(), _this.frequent.Keys()...)
+}
+
+// Entries returns recent entries then frequent entries, from oldest to newest in each list
+func (_this *TwoQueue) Entries() []Entry {
+	return append(_this.recent.Entries(), _this.frequent.Entries()...)
+}
+
+func (_this *TwoQueue) Len() int {
+	return _this.recent.Len() + _this.frequent.Len()
+}
+
+func (_this *TwoQueue) Purge() {
+	_this.recent.Purge()
+	_this.frequent.Purge()
+	_this.recentEvict.Purge()
+}
+
+func (_this *TwoQueue) Resize(size int) (evicted int) {
+	for _this.recent.len()+_this.frequent.len() > size {
+		_this.evictOne(false)
+		evicted++
+	}
+	_this.setSize(size)
+	return
+}
+
+func (_this *TwoQueue) Cost() int64 {
+	return _this.recent.Cost() + _this.frequent.Cost()
+}
+
+func (_this *TwoQueue) RemoveExpired() int {
+	return _this.recent.RemoveExpired() + _this.frequent.RemoveExpired()
+}
@@ -0,0 +1,100 @@
+package lru
+
+import (
+	"github.com/1infras/go-kit/lib/cache/internal/events"
+	"github.com/1infras/go-kit/lib/cache/lru/core"
+)
+
+// EventType tells what happened to an entry
+type EventType int
+
+const (
+	// EventAdd is used when a key is added
+	EventAdd EventType = iota
+	// EventUpdate is used when the value of a key in the cache is replaced
+	EventUpdate
+	// EventEvict is used when an entry is evicted to respect the size or the max cost
+	EventEvict
+	// EventExpire is used when an expired entry is removed
+	EventExpire
+	// EventRemove is used when an entry is removed explicitly or the cache is purged
+	EventRemove
+)
+
+func (_this EventType) String() string {
+	switch _this {
+	case EventAdd:
+		return "add"
+	case EventUpdate:
+		return "update"
+	case EventEvict:
+		return "evict"
+	case EventExpire:
+		return "expire"
+	case EventRemove:
+		return "remove"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is sent to subscribers of a cache
+type Event struct {
+	Type  EventType
+	Key   interface{}
+	Value interface{}
+	// Reason is why the entry has left the cache, it is only set for EventEvict, EventExpire and EventRemove
+	Reason core.EvictReason
+}
+
+// EventHandler is called for every event while the cache is locked, it must return quickly and must not call the cache
+type EventHandler func(event Event)
+
+// SubscribeChannel subscribes a channel of the given size to events of client, events are dropped when the channel is full.
+// The channel is closed by unsubscribe
+func SubscribeChannel(client Client, size int) (ch <-chan Event, unsubscribe func()) {
+	return events.SubscribeChannel(client.Subscribe, size)
+}
+
+// emitter sends events to the handlers subscribed to a cache
+type emitter struct {
+	events.Subscribers[Event]
+}
+
+func newEmitter() *emitter {
+	return &emitter{}
+}
+
+// added emits EventAdd or EventUpdate of a key has been added, existed is whether the key was in the cache before
+func (_this *emitter) added(key, value interface{}, existed bool) {
+	if !_this.Enabled() {
+		return
+	}
+
+	event := Event{Type: EventAdd, Key: key, Value: value}
+	if existed {
+		event.Type = EventUpdate
+	}
+	_this.Emit(event)
+}
+
+// onEvict wraps the eviction callback of the cache to emit an event for every entry leaves the cache
+func (_this *emitter) onEvict(onEvict core.EvictReasonCallback) core.EvictReasonCallback {
+	return func(key interface{}, value interface{}, reason core.EvictReason) {
+		if onEvict != nil {
+			onEvict(key, value, reason)
+		}
+		if !_this.Enabled() {
+			return
+		}
+
+		event := Event{Type: EventRemove, Key: key, Value: value, Reason: reason}
+		switch reason {
+		case core.EvictReasonCapacity:
+			event.Type = EventEvict
+		case core.EvictReasonExpired:
+			event.Type = EventExpire
+		}
+		_this.Emit(event)
+	}
+}
@@ -0,0 +1,153 @@
+package lru
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1infras/go-kit/lib/cache/lru/core"
+)
+
+type recorder struct {
+	lock   sync.Mutex
+	events []Event
+}
+
+func (_this *recorder) handle(event Event) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	_this.events = append(_this.events, event)
+}
+
+func (_this *recorder) types() []EventType {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+	types := make([]EventType, len(_this.events))
+	for i, event := range _this.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func testEvents(t *testing.T, name string, l Client) {
+	ctx := context.Background()
+
+	var evicted []interface{}
+	r := &recorder{}
+	unsubscribe := l.Subscribe(r.handle)
+
+	l.Add(ctx, 1, 1, 0)
+	l.Add(ctx, 1, 2, 0)
+	l.Add(ctx, 2, 2, 0)
+	l.Add(ctx, 3, 3, 0)
+	l.Add(ctx, 4, 4, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	l.RemoveExpired(ctx)
+	l.Remove(ctx, 3)
+	l.ContainsOrAdd(ctx, 5, 5, 0)
+	l.Purge(ctx)
+
+	expected := []EventType{EventAdd, EventUpdate, EventAdd, EventEvict, EventAdd, EventEvict, EventAdd, EventExpire, EventRemove, EventAdd, EventRemove}
+	types := r.types()
+	if len(types) != len(expected) {
+		t.Fatalf("%s bad events: %v", name, types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("%s bad event %d: %v", name, i, types)
+		}
+	}
+	for _, event := range r.events {
+		if event.Type == EventEvict {
+			evicted = append(evicted, event.Key)
+			if event.Reason != core.EvictReasonCapacity {
+				t.Errorf("%s bad reason: %v", name, event.Reason)
+			}
+		}
+	}
+	if len(evicted) != 2 || evicted[0] != 1 || evicted[1] != 2 {
+		t.Errorf("%s bad evicted: %v", name, evicted)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	l.Add(ctx, 6, 6, 0)
+	if len(r.types()) != len(expected) {
+		t.Errorf("%s should not have an event after unsubscribe", name)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	var onEvicted []interface{}
+	l, err := NewWithEvict(2, func(key interface{}, value interface{}) {
+		onEvicted = append(onEvicted, key)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	testEvents(t, "lru", l)
+	if len(onEvicted) == 0 {
+		t.Errorf("the eviction callback should have been kept")
+	}
+
+}
+
+func TestShardedEvents(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := NewSharded(4, 8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	r := &recorder{}
+	l.Subscribe(r.handle)
+
+	l.Add(ctx, "a", 1, 0)
+	l.Add(ctx, "a", 2, 0)
+	l.Remove(ctx, "a")
+	types := r.types()
+	if len(types) != 3 || types[0] != EventAdd || types[1] != EventUpdate || types[2] != EventRemove {
+		t.Fatalf("bad events: %v", types)
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Add(ctx, i, i, 0)
+	}
+	counts := make(map[EventType]int)
+	for _, eventType := range r.types()[3:] {
+		counts[eventType]++
+	}
+	if counts[EventAdd] != 100 || counts[EventEvict] != 100-l.Len(ctx) {
+		t.Errorf("bad events: %v", counts)
+	}
+}
+
+func TestSubscribeChannel(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := New(10)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	events, unsubscribe := SubscribeChannel(l, 2)
+	l.Add(ctx, 1, 1, 0)
+	l.Add(ctx, 2, 2, 0)
+	// The channel is full, the event is dropped
+	l.Add(ctx, 3, 3, 0)
+
+	if event := <-events; event.Type != EventAdd || event.Key != 1 {
+		t.Errorf("bad event: %v", event)
+	}
+	if event := <-events; event.Key != 2 {
+		t.Errorf("bad event: %v", event)
+	}
+
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Errorf("the channel should have been closed")
+	}
+	l.Add(ctx, 4, 4, 0)
+}
@@ -1,6 +1,7 @@
 package lru
 
 import (
+	"bytes"
 	"context"
 	"reflect"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 
+	"github.com/1infras/go-kit/lib/hook/common"
 	"github.com/1infras/go-kit/logger"
 )
 
@@ -39,3 +41,79 @@ func TestHook_AddHook(t *testing.T) {
 	assert.Equal(t, true, ok)
 	assert.Equal(t, "1", v)
 }
+
+type operationHook struct {
+	operations []common.Operation
+}
+
+func (_this *operationHook) BeforeOperation(ctx context.Context, op *common.Operation) context.Context {
+	return ctx
+}
+
+func (_this *operationHook) AfterOperation(ctx context.Context, op *common.Operation) {
+	_this.operations = append(_this.operations, *op)
+}
+
+func TestHook_AddOperationHook(t *testing.T) {
+	ctx := context.Background()
+
+	single, err := New(100)
+	assert.Nil(t, err)
+	sharded, err := NewSharded(4, 100)
+	assert.Nil(t, err)
+
+	for _, l := range []Client{single, sharded} {
+		hook := &operationHook{}
+		l.AddOperationHook(hook)
+
+		l.Add(ctx, "hello", "1", 0)
+		l.Get(ctx, "hello")
+		l.Len(ctx)
+		_, err = l.Restore(ctx, bytes.NewReader([]byte("invalid")))
+		assert.NotNil(t, err)
+
+		assert.Len(t, hook.operations, 4)
+		assert.Equal(t, "add", hook.operations[0].Name)
+		assert.Equal(t, "hello", hook.operations[0].Key)
+		assert.Equal(t, "get", hook.operations[1].Name)
+		assert.Equal(t, "hello", hook.operations[1].Key)
+		assert.Equal(t, "len", hook.operations[2].Name)
+		assert.Nil(t, hook.operations[2].Key)
+		assert.Equal(t, "restore", hook.operations[3].Name)
+		assert.Equal(t, err, hook.operations[3].Err)
+	}
+}
+
+func TestInterceptorOutsideLock(t *testing.T) {
+	ctx := context.Background()
+
+	single, err := New(100)
+	assert.Nil(t, err)
+	sharded, err := NewSharded(4, 100)
+	assert.Nil(t, err)
+
+	for _, l := range []Client{single, sharded} {
+		l := l
+		// Interceptors run without the lock of the cache, so they may call the cache
+		l.AddInterceptor(func(ctx context.Context, op *common.Operation, next common.Next) error {
+			if op.Name == "add" || op.Name == "contains_or_add" {
+				l.Peek(ctx, op.Key)
+			}
+			return next(ctx)
+		})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			l.Add(ctx, "a", 1, 0)
+			l.ContainsOrAdd(ctx, "b", 2, 0)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("interceptor is called under the lock of the cache")
+		}
+		assert.Equal(t, 2, l.Len(ctx))
+	}
+}
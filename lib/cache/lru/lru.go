@@ -2,6 +2,8 @@ package lru
 
 import (
 	"context"
+	"errors"
+	"io"
 	"sync"
 	"time"
 
@@ -9,11 +11,13 @@ import (
 	"github.com/1infras/go-kit/lib/hook/common"
 )
 
-// Client is a thread-safe fixed size LRU cache.
+// Client is a thread-safe fixed size cache, it evicts the least recently used entries unless another policy is chosen.
 type Client interface {
 	Purge(ctx context.Context)
 	Add(ctx context.Context, key, value interface{}, expiration time.Duration) (evicted bool)
+	AddWithSoftExpiration(ctx context.Context, key, value interface{}, softExpiration, expiration time.Duration) (evicted bool)
 	Get(ctx context.Context, key interface{}) (value interface{}, ok bool)
+	GetWithStale(ctx context.Context, key interface{}) (value interface{}, stale, ok bool)
 	Contains(ctx context.Context, key interface{}) bool
 	Peek(ctx context.Context, key interface{}) (value interface{}, ok bool)
 	ContainsOrAdd(ctx context.Context, key, value interface{}, expiration time.Duration) (ok, evicted bool)
@@ -24,13 +28,44 @@ type Client interface {
 	GetOldest(ctx context.Context) (key, value interface{}, ok bool)
 	Keys(ctx context.Context) []interface{}
 	Len(ctx context.Context) int
+	Cost(ctx context.Context) int64
+	RemoveExpired(ctx context.Context) (removed int)
+	// Snapshot writes entries which are not expired with their remaining expiration to w, from oldest to newest.
+	// Keys and values must be strings, []byte, booleans or numbers
+	Snapshot(ctx context.Context, w io.Writer) error
+	// Restore adds entries of a snapshot which are not expired yet, the recency is kept but the statistics of
+	// policies other than LRU start over so some entries may not be admitted
+	Restore(ctx context.Context, r io.Reader) (restored int, err error)
+	// Subscribe calls handler for every event of the cache until unsubscribe is called
+	Subscribe(handler EventHandler) (unsubscribe func())
 	AddHook(hook common.HookProcess)
+	// AddOperationHook adds a hook which sees the key and the start time of every operation
+	AddOperationHook(hook common.OperationHook)
+	// AddInterceptor adds an interceptor wraps every operation, hooks and interceptors added first are the outermost.
+	// Operations short-circuited by an interceptor return zero results
+	AddInterceptor(interceptor common.Interceptor)
+	// Close stops the janitor, the cache is still usable
+	Close()
+}
+
+// Options is used to construct a cache with NewWithOptions
+type Options struct {
+	core.Options
+	// CleanupInterval is how often the janitor removes expired entries, 0 disables the janitor
+	CleanupInterval time.Duration
+	// Shards splits the cache in independently locked shards selected by the hash of keys, it is rounded up
+	// to a power of two. 0 or 1 means a single shard
+	Shards int
 }
 
 type lru struct {
-	lru  core.LRUCache
-	lock sync.RWMutex
-	hook *common.Hook
+	lru    core.LRUCache
+	lock   sync.RWMutex
+	hook   *common.Hook
+	events *emitter
+
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 // New creates an LRU of the given size.
@@ -52,79 +87,217 @@ func NewWithEvict(size int, onEvicted func(key interface{}, value interface{}))
 // NewWithEvict constructs a fixed size cache with the given eviction
 // callback.
 func NewWithEvictExpiration(size int, expiration time.Duration, onEvicted func(key interface{}, value interface{})) (Client, error) {
-	client, err := core.NewLRUWithExpire(size, expiration, onEvicted)
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return NewWithOptions(Options{Options: core.Options{
+		Size:    size,
+		Expire:  expiration,
+		OnEvict: withoutReason(onEvicted),
+	}})
+}
+
+// NewWithMaxCost constructs a cache evicts entries when there are more than size entries or their total cost
+// exceeds maxCost, size 0 means the number of entries is unbounded. cost defaults to the length of []byte and string values
+func NewWithMaxCost(size int, maxCost int64, expiration time.Duration, cost core.CostFunc, onEvicted func(key interface{}, value interface{})) (Client, error) {
+	if size < 0 || maxCost <= 0 {
+		return nil, errors.New("must provide a positive max cost and a non negative size")
+	}
+	return NewWithOptions(Options{Options: core.Options{
+		Size:    size,
+		MaxCost: maxCost,
+		Expire:  expiration,
+		Cost:    cost,
+		OnEvict: withoutReason(onEvicted),
+	}})
+}
+
+// withoutReason adapts an eviction callback without reason
+func withoutReason(onEvicted func(key interface{}, value interface{})) core.EvictReasonCallback {
+	if onEvicted == nil {
+		return nil
+	}
+	return func(key interface{}, value interface{}, reason core.EvictReason) {
+		onEvicted(key, value)
+	}
+}
+
+// New2Q creates a 2Q cache of the given size, it keeps entries used repeatedly apart from entries used once
+// so scans do not flush them out.
+func New2Q(size int) (Client, error) {
+	return NewWithOptions(Options{Options: core.Options{Size: size, Policy: core.Policy2Q}})
+}
+
+// NewARC creates an Adaptive Replacement Cache of the given size.
+func NewARC(size int) (Client, error) {
+	return NewWithOptions(Options{Options: core.Options{Size: size, Policy: core.PolicyARC}})
+}
+
+// NewTinyLFU creates a W-TinyLFU cache of the given size, it admits an entry only if it is used more often than
+// the entry it replaces.
+func NewTinyLFU(size int) (Client, error) {
+	return NewWithOptions(Options{Options: core.Options{Size: size, Policy: core.PolicyTinyLFU}})
+}
+
+// NewWithOptions constructs a cache with options, the eviction policy defaults to LRU, a janitor removes expired entries in background
+// every CleanupInterval until Close is called
+func NewWithOptions(options Options) (Client, error) {
+	if options.CleanupInterval < 0 {
+		return nil, errors.New("must provide a non negative cleanup interval")
+	}
+	if options.Shards < 0 {
+		return nil, errors.New("must provide a non negative number of shards")
+	}
+	if options.Shards > 1 {
+		return newSharded(options)
+	}
+
+	events := newEmitter()
+	options.OnEvict = events.onEvict(options.OnEvict)
+
+	client, err := core.New(options.Options)
 	if err != nil {
 		return nil, err
 	}
 	c := &lru{
-		lru:  client,
-		hook: &common.Hook{},
+		lru:    client,
+		hook:   &common.Hook{},
+		events: events,
+		stop:   make(chan struct{}),
+	}
+
+	if options.CleanupInterval > 0 {
+		go janitor(c, options.CleanupInterval, c.stop)
 	}
 
 	return c, nil
 }
 
+// janitor removes expired entries of the client every interval until stop is closed
+func janitor(client Client, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			client.RemoveExpired(context.Background())
+		}
+	}
+}
+
+// Close stops the janitor.
+func (_this *lru) Close() {
+	_this.stopOnce.Do(func() {
+		if _this.stop != nil {
+			close(_this.stop)
+		}
+	})
+}
+
 // AddHook is used wrap Processing before and after for a Process
 func (_this *lru) AddHook(hook common.HookProcess) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
 	_this.hook.AddHook(hook)
 }
 
+// AddOperationHook adds a hook which sees the key and the start time of every operation
+func (_this *lru) AddOperationHook(hook common.OperationHook) {
+	_this.hook.AddOperationHook(hook)
+}
+
+// AddInterceptor adds an interceptor wraps every operation
+func (_this *lru) AddInterceptor(interceptor common.Interceptor) {
+	_this.hook.AddInterceptor(interceptor)
+}
+
+// process calls fn with the context passed by the hooks, key is nil for operations on the whole cache
+func (_this *lru) process(ctx context.Context, name string, key interface{}, fn func(ctx context.Context)) {
+	_ = _this.hook.ProcessOperation(ctx, common.Operation{Name: name, Key: key}, func(ctx context.Context) error {
+		fn(ctx)
+		return nil
+	})
+}
+
+// Subscribe calls handler for every event of the cache until unsubscribe is called
+func (_this *lru) Subscribe(handler EventHandler) (unsubscribe func()) {
+	return _this.events.Subscribe(handler)
+}
+
 // Purge is used to completely clear the cache.
 func (_this *lru) Purge(ctx context.Context) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "purge", nil, func(context.Context) {
+		_this.lock.Lock()
 		_this.lru.Purge()
-	}, "purge")
+		_this.lock.Unlock()
+	})
 }
 
 // Add adds a value to the cache. Returns true if an eviction occurred.
 func (_this *lru) Add(ctx context.Context, key, value interface{}, expiration time.Duration) (evicted bool) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "add", key, func(context.Context) {
+		_this.lock.Lock()
+		existed := _this.events.Enabled() && _this.lru.Contains(key)
 		evicted = _this.lru.Add(key, value, expiration)
-	}, "add")
+		_this.events.added(key, value, existed)
+		_this.lock.Unlock()
+	})
+	return
+}
+
+// AddWithSoftExpiration adds a value to the cache which becomes stale after softExpiration.
+// Returns true if an eviction occurred.
+func (_this *lru) AddWithSoftExpiration(ctx context.Context, key, value interface{}, softExpiration, expiration time.Duration) (evicted bool) {
+	_this.process(ctx, "add", key, func(context.Context) {
+		_this.lock.Lock()
+		existed := _this.events.Enabled() && _this.lru.Contains(key)
+		evicted = _this.lru.AddWithSoftExpiration(key, value, softExpiration, expiration)
+		_this.events.added(key, value, existed)
+		_this.lock.Unlock()
+	})
 	return
 }
 
 // Get looks up a key's value from the cache.
 func (_this *lru) Get(ctx context.Context, key interface{}) (value interface{}, ok bool) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "get", key, func(context.Context) {
+		_this.lock.Lock()
 		value, ok = _this.lru.Get(key)
-	}, "get")
+		_this.lock.Unlock()
+	})
+	return
+}
+
+// GetWithStale looks up a key's value from the cache and reports whether it is stale.
+func (_this *lru) GetWithStale(ctx context.Context, key interface{}) (value interface{}, stale, ok bool) {
+	_this.process(ctx, "get", key, func(context.Context) {
+		_this.lock.Lock()
+		value, stale, ok = _this.lru.GetWithStale(key)
+		_this.lock.Unlock()
+	})
 	return
 }
 
 // Contains checks if a key is in the cache, without updating the
 // recent-ness or deleting it for being stale.
 func (_this *lru) Contains(ctx context.Context, key interface{}) (existed bool) {
-	_this.lock.RLock()
-	defer _this.lock.RUnlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "contains", key, func(context.Context) {
+		_this.lock.RLock()
 		existed = _this.lru.Contains(key)
-	}, "contains")
+		_this.lock.RUnlock()
+	})
 	return
 }
 
 // Peek returns the key value (or undefined if not found) without updating
 // the "recently used"-ness of the key.
 func (_this *lru) Peek(ctx context.Context, key interface{}) (value interface{}, ok bool) {
-	_this.lock.RLock()
-	defer _this.lock.RUnlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "peek", key, func(context.Context) {
+		_this.lock.RLock()
 		value, ok = _this.lru.Peek(key)
-	}, "peek")
+		_this.lock.RUnlock()
+	})
 	return
 }
 
@@ -132,20 +305,16 @@ func (_this *lru) Peek(ctx context.Context, key interface{}) (value interface{},
 // recent-ness or deleting it for being stale, and if not, adds the value.
 // Returns whether found and whether an eviction occurred.
 func (_this *lru) ContainsOrAdd(ctx context.Context, key, value interface{}, expiration time.Duration) (ok, evicted bool) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
+	_this.process(ctx, "contains_or_add", key, func(context.Context) {
+		_this.lock.Lock()
+		defer _this.lock.Unlock()
 
-	_this.hook.Process(ctx, func() {
-		ok = _this.lru.Contains(key)
-	}, "contains")
-
-	if ok {
-		return
-	}
-
-	_this.hook.Process(ctx, func() {
+		if ok = _this.lru.Contains(key); ok {
+			return
+		}
 		evicted = _this.lru.Add(key, value, expiration)
-	}, "add")
+		_this.events.added(key, value, false)
+	})
 	return
 }
 
@@ -153,85 +322,121 @@ func (_this *lru) ContainsOrAdd(ctx context.Context, key, value interface{}, exp
 // recent-ness or deleting it for being stale, and if not, adds the value.
 // Returns whether found and whether an eviction occurred.
 func (_this *lru) PeekOrAdd(ctx context.Context, key, value interface{}, expiration time.Duration) (previous interface{}, ok, evicted bool) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
-		previous, ok = _this.lru.Peek(key)
-	}, "peek")
-
-	if ok {
-		return
-	}
+	_this.process(ctx, "peek_or_add", key, func(context.Context) {
+		_this.lock.Lock()
+		defer _this.lock.Unlock()
 
-	_this.hook.Process(ctx, func() {
+		if previous, ok = _this.lru.Peek(key); ok {
+			return
+		}
 		evicted = _this.lru.Add(key, value, expiration)
-	}, "add")
+		_this.events.added(key, value, false)
+	})
 	return
 }
 
 // Remove removes the provided key from the cache.
 func (_this *lru) Remove(ctx context.Context, key interface{}) (present bool) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "remove", key, func(context.Context) {
+		_this.lock.Lock()
 		present = _this.lru.Remove(key)
-	}, "remove")
+		_this.lock.Unlock()
+	})
 	return
 }
 
 // Resize changes the cache size.
 func (_this *lru) Resize(ctx context.Context, size int) (evicted int) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "resize", nil, func(context.Context) {
+		_this.lock.Lock()
 		evicted = _this.lru.Resize(size)
-	}, "resize")
+		_this.lock.Unlock()
+	})
 	return
 }
 
 // RemoveOldest removes the oldest item from the cache.
 func (_this *lru) RemoveOldest(ctx context.Context) (key, value interface{}, ok bool) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "remove_oldest", nil, func(context.Context) {
+		_this.lock.Lock()
 		key, value, ok = _this.lru.RemoveOldest()
-	}, "remove_oldest")
+		_this.lock.Unlock()
+	})
 	return
 }
 
 // GetOldest returns the oldest entry
 func (_this *lru) GetOldest(ctx context.Context) (key, value interface{}, ok bool) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "get_oldest", nil, func(context.Context) {
+		_this.lock.Lock()
 		key, value, ok = _this.lru.GetOldest()
-	}, "get_oldest")
+		_this.lock.Unlock()
+	})
 	return
 }
 
-// Keys returns a slice of the keys in the cache, from oldest to newest.
+// Keys returns a slice of the keys in the cache which are not expired, from oldest to newest.
 func (_this *lru) Keys(ctx context.Context) (keys []interface{}) {
-	_this.lock.RLock()
-	defer _this.lock.RUnlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "keys", nil, func(context.Context) {
+		_this.lock.RLock()
 		keys = _this.lru.Keys()
-	}, "keys")
+		_this.lock.RUnlock()
+	})
 	return
 }
 
-// Len returns the number of items in the cache.
+// Len returns the number of items in the cache which are not expired.
 func (_this *lru) Len(ctx context.Context) (length int) {
-	_this.lock.RLock()
-	defer _this.lock.RUnlock()
-
-	_this.hook.Process(ctx, func() {
+	_this.process(ctx, "len", nil, func(context.Context) {
+		_this.lock.RLock()
 		length = _this.lru.Len()
-	}, "len")
+		_this.lock.RUnlock()
+	})
+	return
+}
+
+// Cost returns the total cost of entries in the cache.
+func (_this *lru) Cost(ctx context.Context) (cost int64) {
+	_this.process(ctx, "cost", nil, func(context.Context) {
+		_this.lock.RLock()
+		cost = _this.lru.Cost()
+		_this.lock.RUnlock()
+	})
+	return
+}
+
+// RemoveExpired removes every expired item from the cache.
+func (_this *lru) RemoveExpired(ctx context.Context) (removed int) {
+	_this.process(ctx, "remove_expired", nil, func(context.Context) {
+		_this.lock.Lock()
+		removed = _this.lru.RemoveExpired()
+		_this.lock.Unlock()
+	})
+	return
+}
+
+// Snapshot writes entries which are not expired with their remaining expiration to w, from oldest to newest.
+func (_this *lru) Snapshot(ctx context.Context, w io.Writer) error {
+	return _this.hook.ProcessOperation(ctx, common.Operation{Name: "snapshot"}, func(context.Context) error {
+		_this.lock.RLock()
+		entries := _this.lru.Entries()
+		_this.lock.RUnlock()
+
+		return writeSnapshot(w, entries)
+	})
+}
+
+// Restore adds entries of a snapshot which are not expired yet.
+func (_this *lru) Restore(ctx context.Context, r io.Reader) (restored int, err error) {
+	err = _this.hook.ProcessOperation(ctx, common.Operation{Name: "restore"}, func(context.Context) (err error) {
+		restored, err = readSnapshot(r, func(key, value interface{}, softExpiration, expiration time.Duration) {
+			_this.lock.Lock()
+			existed := _this.events.Enabled() && _this.lru.Contains(key)
+			_this.lru.AddWithSoftExpiration(key, value, softExpiration, expiration)
+			_this.events.added(key, value, existed)
+			_this.lock.Unlock()
+		})
+		return err
+	})
 	return
 }
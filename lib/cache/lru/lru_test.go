@@ -3,7 +3,11 @@ package lru
 import (
 	"context"
 	"math/rand"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/1infras/go-kit/lib/cache/lru/core"
 )
 
 func BenchmarkLRU_Rand(b *testing.B) {
@@ -309,4 +313,98 @@ func TestLRUResize(t *testing.T) {
 	if !l.Contains(ctx, 3) || !l.Contains(ctx, 4) {
 		t.Errorf("Client should have contained 2 elements")
 	}
-}
\ No newline at end of file
+}
+
+func TestLRUMaxCost(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := NewWithMaxCost(0, 8, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(ctx, 1, []byte("aaaa"), 0)
+	l.Add(ctx, 2, []byte("bbbb"), 0)
+	if l.Cost(ctx) != 8 {
+		t.Errorf("bad cost: %v", l.Cost(ctx))
+	}
+
+	if !l.Add(ctx, 3, []byte("cc"), 0) {
+		t.Errorf("should have an eviction")
+	}
+	if l.Contains(ctx, 1) || l.Cost(ctx) != 6 {
+		t.Errorf("1 should have been evicted: %v", l.Cost(ctx))
+	}
+}
+
+func TestLRUJanitor(t *testing.T) {
+	ctx := context.Background()
+
+	var lock sync.Mutex
+	reasons := make(map[interface{}]core.EvictReason)
+	l, err := NewWithOptions(Options{
+		Options: core.Options{
+			Size: 10,
+			OnEvict: func(key interface{}, value interface{}, reason core.EvictReason) {
+				lock.Lock()
+				defer lock.Unlock()
+				reasons[key] = reason
+			},
+		},
+		CleanupInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(ctx, 1, 1, 20*time.Millisecond)
+	l.Add(ctx, 2, 2, 0)
+
+	time.Sleep(100 * time.Millisecond)
+
+	lock.Lock()
+	if reason, ok := reasons[1]; !ok || reason != core.EvictReasonExpired {
+		t.Errorf("1 should have been expired by the janitor: %v", reasons)
+	}
+	lock.Unlock()
+
+	if _, ok := l.Peek(ctx, 2); !ok {
+		t.Errorf("2 should not have been removed")
+	}
+
+	l.Close()
+	l.Close()
+
+	if _, err := NewWithOptions(Options{Options: core.Options{Size: 1}, CleanupInterval: -1}); err == nil {
+		t.Errorf("should have an error with a negative cleanup interval")
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	constructors := map[string]func(int) (Client, error){
+		"2q":      New2Q,
+		"arc":     NewARC,
+		"tinylfu": NewTinyLFU,
+	}
+
+	for name, constructor := range constructors {
+		l, err := constructor(128)
+		if err != nil {
+			t.Fatalf("%s err: %v", name, err)
+		}
+
+		for i := 0; i < 256; i++ {
+			l.Add(ctx, i, i, 0)
+		}
+		if l.Len(ctx) != 128 {
+			t.Errorf("%s bad len: %v", name, l.Len(ctx))
+		}
+		for _, k := range l.Keys(ctx) {
+			if v, ok := l.Get(ctx, k); !ok || v != k {
+				t.Errorf("%s bad key: %v", name, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package lru
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/1infras/go-kit/lib/cache/lru/core"
+	"github.com/1infras/go-kit/lib/hook/common"
+)
+
+// DefaultShards is the number of shards of NewSharded when it is not given
+const DefaultShards = 16
+
+// fibonacci is 2^64 divided by the golden ratio, multiplying by it spreads hashes over the high bits
+const fibonacci = 0x9e3779b97f4a7c15
+
+type shard struct {
+	lru  core.LRUCache
+	lock sync.RWMutex
+}
+
+// sharded splits entries over independently locked shards so operations on different keys do not contend,
+// size, cost and eviction are per shard
+type sharded struct {
+	shards []*shard
+	shift  uint
+
+	hook   *common.Hook
+	events *emitter
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSharded creates an LRU of the given size split in shards, every shard holds size/shards entries and is evicted on its own.
+// shards defaults to DefaultShards when it is 0
+func NewSharded(shards, size int) (Client, error) {
+	if shards == 0 {
+		shards = DefaultShards
+	}
+	return NewWithOptions(Options{Options: core.Options{Size: size}, Shards: shards})
+}
+
+func newSharded(options Options) (Client, error) {
+	n := 1
+	for n < options.Shards {
+		n <<= 1
+	}
+	// every shard holds at least one entry
+	for n > 1 && options.Size > 0 && n > options.Size {
+		n >>= 1
+	}
+
+	c := &sharded{
+		shards: make([]*shard, n),
+		shift:  64 - log2(n),
+		hook:   &common.Hook{},
+		events: newEmitter(),
+		stop:   make(chan struct{}),
+	}
+
+	shardOptions := options.Options
+	shardOptions.OnEvict = c.events.onEvict(options.OnEvict)
+	shardOptions.Size = int(perShard(int64(options.Size), n))
+	shardOptions.MaxCost = perShard(options.MaxCost, n)
+	for i := range c.shards {
+		client, err := core.New(shardOptions)
+		if err != nil {
+			return nil, err
+		}
+		c.shards[i] = &shard{lru: client}
+	}
+
+	if options.CleanupInterval > 0 {
+		go janitor(c, options.CleanupInterval, c.stop)
+	}
+
+	return c, nil
+}
+
+// perShard splits total over n shards rounding up, 0 stays unbounded
+func perShard(total int64, n int) int64 {
+	if total <= 0 {
+		return total
+	}
+	return (total + int64(n) - 1) / int64(n)
+}
+
+func log2(n int) uint {
+	var bits uint
+	for n > 1 {
+		n >>= 1
+		bits++
+	}
+	return bits
+}
+
+// shard selects the shard of key by the high bits of its hash, policies hash keys as well so
+// the low bits stay spread inside a shard
+func (_this *sharded) shard(key interface{}) *shard {
+	if len(_this.shards) == 1 {
+		return _this.shards[0]
+	}
+	return _this.shards[(core.HashKey(key)*fibonacci)>>_this.shift]
+}
+
+// process calls fn with the context passed by the hooks, key is nil for operations on the whole cache
+func (_this *sharded) process(ctx context.Context, name string, key interface{}, fn func(ctx context.Context)) {
+	_ = _this.processOperation(ctx, common.Operation{Name: name, Key: key}, func(ctx context.Context) error {
+		fn(ctx)
+		return nil
+	})
+}
+
+func (_this *sharded) processOperation(ctx context.Context, op common.Operation, fn func(ctx context.Context) error) error {
+	return _this.hook.ProcessOperation(ctx, op, fn)
+}
+
+// Close stops the janitor.
+func (_this *sharded) Close() {
+	_this.stopOnce.Do(func() {
+		close(_this.stop)
+	})
+}
+
+// AddHook is used wrap Processing before and after for a Process
+func (_this *sharded) AddHook(hook common.HookProcess) {
+	_this.hook.AddHook(hook)
+}
+
+// AddOperationHook adds a hook which sees the key and the start time of every operation
+func (_this *sharded) AddOperationHook(hook common.OperationHook) {
+	_this.hook.AddOperationHook(hook)
+}
+
+// AddInterceptor adds an interceptor wraps every operation
+func (_this *sharded) AddInterceptor(interceptor common.Interceptor) {
+	_this.hook.AddInterceptor(interceptor)
+}
+
+// Subscribe calls handler for every event of the cache until unsubscribe is called, events of a key are ordered
+// but events of keys in different shards may be delivered concurrently
+func (_this *sharded) Subscribe(handler EventHandler) (unsubscribe func()) {
+	return _this.events.Subscribe(handler)
+}
+
+// Purge is used to completely clear the cache.
+func (_this *sharded) Purge(ctx context.Context) {
+	_this.process(ctx, "purge", nil, func(context.Context) {
+		for _, s := range _this.shards {
+			s.lock.Lock()
+			s.lru.Purge()
+			s.lock.Unlock()
+		}
+	})
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (_this *sharded) Add(ctx context.Context, key, value interface{}, expiration time.Duration) (evicted bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "add", key, func(context.Context) {
+		s.lock.Lock()
+		existed := _this.events.Enabled() && s.lru.Contains(key)
+		evicted = s.lru.Add(key, value, expiration)
+		_this.events.added(key, value, existed)
+		s.lock.Unlock()
+	})
+	return
+}
+
+// AddWithSoftExpiration adds a value to the cache which becomes stale after softExpiration.
+// Returns true if an eviction occurred.
+func (_this *sharded) AddWithSoftExpiration(ctx context.Context, key, value interface{}, softExpiration, expiration time.Duration) (evicted bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "add", key, func(context.Context) {
+		s.lock.Lock()
+		existed := _this.events.Enabled() && s.lru.Contains(key)
+		evicted = s.lru.AddWithSoftExpiration(key, value, softExpiration, expiration)
+		_this.events.added(key, value, existed)
+		s.lock.Unlock()
+	})
+	return
+}
+
+// Get looks up a key's value from the cache.
+func (_this *sharded) Get(ctx context.Context, key interface{}) (value interface{}, ok bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "get", key, func(context.Context) {
+		s.lock.Lock()
+		value, ok = s.lru.Get(key)
+		s.lock.Unlock()
+	})
+	return
+}
+
+// GetWithStale looks up a key's value from the cache and reports whether it is stale.
+func (_this *sharded) GetWithStale(ctx context.Context, key interface{}) (value interface{}, stale, ok bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "get", key, func(context.Context) {
+		s.lock.Lock()
+		value, stale, ok = s.lru.GetWithStale(key)
+		s.lock.Unlock()
+	})
+	return
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (_this *sharded) Contains(ctx context.Context, key interface{}) (existed bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "contains", key, func(context.Context) {
+		s.lock.RLock()
+		existed = s.lru.Contains(key)
+		s.lock.RUnlock()
+	})
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (_this *sharded) Peek(ctx context.Context, key interface{}) (value interface{}, ok bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "peek", key, func(context.Context) {
+		s.lock.RLock()
+		value, ok = s.lru.Peek(key)
+		s.lock.RUnlock()
+	})
+	return
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns whether found and whether an eviction occurred.
+func (_this *sharded) ContainsOrAdd(ctx context.Context, key, value interface{}, expiration time.Duration) (ok, evicted bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "contains_or_add", key, func(context.Context) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if ok = s.lru.Contains(key); ok {
+			return
+		}
+		evicted = s.lru.Add(key, value, expiration)
+		_this.events.added(key, value, false)
+	})
+	return
+}
+
+// PeekOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns whether found and whether an eviction occurred.
+func (_this *sharded) PeekOrAdd(ctx context.Context, key, value interface{}, expiration time.Duration) (previous interface{}, ok, evicted bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "peek_or_add", key, func(context.Context) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if previous, ok = s.lru.Peek(key); ok {
+			return
+		}
+		evicted = s.lru.Add(key, value, expiration)
+		_this.events.added(key, value, false)
+	})
+	return
+}
+
+// Remove removes the provided key from the cache.
+func (_this *sharded) Remove(ctx context.Context, key interface{}) (present bool) {
+	s := _this.shard(key)
+
+	_this.process(ctx, "remove", key, func(context.Context) {
+		s.lock.Lock()
+		present = s.lru.Remove(key)
+		s.lock.Unlock()
+	})
+	return
+}
+
+// Resize changes the cache size, it is split over the shards like the size the cache is created with.
+func (_this *sharded) Resize(ctx context.Context, size int) (evicted int) {
+	_this.process(ctx, "resize", nil, func(context.Context) {
+		for _, s := range _this.shards {
+			s.lock.Lock()
+			evicted += s.lru.Resize(int(perShard(int64(size), len(_this.shards))))
+			s.lock.Unlock()
+		}
+	})
+	return
+}
+
+// RemoveOldest removes the oldest item of the first non empty shard, items are only ordered within a shard.
+func (_this *sharded) RemoveOldest(ctx context.Context) (key, value interface{}, ok bool) {
+	_this.process(ctx, "remove_oldest", nil, func(context.Context) {
+		for _, s := range _this.shards {
+			s.lock.Lock()
+			key, value, ok = s.lru.RemoveOldest()
+			s.lock.Unlock()
+			if ok {
+				return
+			}
+		}
+	})
+	return
+}
+
+// GetOldest returns the oldest entry of the first non empty shard, entries are only ordered within a shard.
+func (_this *sharded) GetOldest(ctx context.Context) (key, value interface{}, ok bool) {
+	_this.process(ctx, "get_oldest", nil, func(context.Context) {
+		for _, s := range _this.shards {
+			s.lock.Lock()
+			key, value, ok = s.lru.GetOldest()
+			s.lock.Unlock()
+			if ok {
+				return
+			}
+		}
+	})
+	return
+}
+
+// Keys returns a slice of the keys in the cache which are not expired, from oldest to newest within each shard.
+func (_this *sharded) Keys(ctx context.Context) (keys []interface{}) {
+	_this.process(ctx, "keys", nil, func(context.Context) {
+		for _, s := range _this.shards {
+			s.lock.RLock()
+			keys = append(keys, s.lru.Keys()...)
+			s.lock.RUnlock()
+		}
+	})
+	return
+}
+
+// Len returns the number of items in the cache which are not expired.
+func (_this *sharded) Len(ctx context.Context) (length int) {
+	_this.process(ctx, "len", nil, func(context.Context) {
+		for _, s := range _this.shards {
+			s.lock.RLock()
+			length += s.lru.Len()
+			s.lock.RUnlock()
+		}
+	})
+	return
+}
+
+// Cost returns the total cost of entries in the cache.
+func (_this *sharded) Cost(ctx context.Context) (cost int64) {
+	_this.process(ctx, "cost", nil, func(context.Context) {
+		for _, s := range _this.shards {
+			s.lock.RLock()
+			cost += s.lru.Cost()
+			s.lock.RUnlock()
+		}
+	})
+	return
+}
+
+// RemoveExpired removes every expired item from the cache, shards are locked one at a time.
+func (_this *sharded) RemoveExpired(ctx context.Context) (removed int) {
+	_this.process(ctx, "remove_expired", nil, func(context.Context) {
+		for _, s := range _this.shards {
+			s.lock.Lock()
+			removed += s.lru.RemoveExpired()
+			s.lock.Unlock()
+		}
+	})
+	return
+}
+
+// Snapshot writes entries which are not expired with their remaining expiration to w, from oldest to newest within each shard.
+func (_this *sharded) Snapshot(ctx context.Context, w io.Writer) error {
+	return _this.processOperation(ctx, common.Operation{Name: "snapshot"}, func(context.Context) error {
+		var entries []core.Entry
+		for _, s := range _this.shards {
+			s.lock.RLock()
+			entries = append(entries, s.lru.Entries()...)
+			s.lock.RUnlock()
+		}
+
+		return writeSnapshot(w, entries)
+	})
+}
+
+// Restore adds entries of a snapshot which are not expired yet to their shards.
+func (_this *sharded) Restore(ctx context.Context, r io.Reader) (restored int, err error) {
+	err = _this.processOperation(ctx, common.Operation{Name: "restore"}, func(context.Context) (err error) {
+		restored, err = readSnapshot(r, func(key, value interface{}, softExpiration, expiration time.Duration) {
+			s := _this.shard(key)
+			s.lock.Lock()
+			existed := _this.events.Enabled() && s.lru.Contains(key)
+			s.lru.AddWithSoftExpiration(key, value, softExpiration, expiration)
+			_this.events.added(key, value, existed)
+			s.lock.Unlock()
+		})
+		return err
+	})
+	return
+}
@@ -0,0 +1,202 @@
+package lru
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1infras/go-kit/lib/cache/lru/core"
+)
+
+type countHook struct {
+	calls int32
+}
+
+func (_this *countHook) BeforeProcess(ctx context.Context, name string) context.Context {
+	atomic.AddInt32(&_this.calls, 1)
+	return ctx
+}
+
+func (_this *countHook) AfterProcess(ctx context.Context, name string) {}
+
+func TestSharded(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := NewSharded(3, 128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if n := len(l.(*sharded).shards); n != 4 {
+		t.Fatalf("shards should have been rounded up to 4: %v", n)
+	}
+
+	hook := &countHook{}
+	l.AddHook(hook)
+
+	for i := 0; i < 64; i++ {
+		l.Add(ctx, fmt.Sprintf("key-%d", i), i, 0)
+	}
+	if l.Len(ctx) != 64 || len(l.Keys(ctx)) != 64 {
+		t.Fatalf("bad len: %v", l.Len(ctx))
+	}
+	for i := 0; i < 64; i++ {
+		if v, ok := l.Get(ctx, fmt.Sprintf("key-%d", i)); !ok || v != i {
+			t.Fatalf("bad value of key-%d: %v", i, v)
+		}
+	}
+	if atomic.LoadInt32(&hook.calls) != 64*2+2 {
+		t.Errorf("bad hook calls: %v", hook.calls)
+	}
+
+	if ok, _ := l.ContainsOrAdd(ctx, "key-1", 0, 0); !ok {
+		t.Errorf("key-1 should have been contained")
+	}
+	if previous, ok, _ := l.PeekOrAdd(ctx, "key-1", 0, 0); !ok || previous != 1 {
+		t.Errorf("bad previous of key-1: %v", previous)
+	}
+	if !l.Remove(ctx, "key-1") || l.Contains(ctx, "key-1") {
+		t.Errorf("key-1 should have been removed")
+	}
+	if _, _, ok := l.RemoveOldest(ctx); !ok || l.Len(ctx) != 62 {
+		t.Errorf("an oldest key should have been removed: %v", l.Len(ctx))
+	}
+
+	for i := 0; i < 1024; i++ {
+		l.Add(ctx, i, i, 0)
+	}
+	if l.Len(ctx) != 128 {
+		t.Errorf("every shard should be full: %v", l.Len(ctx))
+	}
+
+	l.Resize(ctx, 64)
+	if l.Len(ctx) != 64 {
+		t.Errorf("bad len after resize: %v", l.Len(ctx))
+	}
+
+	l.Purge(ctx)
+	if l.Len(ctx) != 0 {
+		t.Errorf("bad len after purge: %v", l.Len(ctx))
+	}
+	if _, _, ok := l.GetOldest(ctx); ok {
+		t.Errorf("should not have an oldest key")
+	}
+
+	if _, err := NewWithOptions(Options{Options: core.Options{Size: 1}, Shards: -1}); err == nil {
+		t.Errorf("should have an error with a negative number of shards")
+	}
+	if l, err := NewSharded(16, 2); err != nil || len(l.(*sharded).shards) != 2 {
+		t.Errorf("a shard should hold at least one entry")
+	}
+}
+
+func TestShardedMaxCostAndJanitor(t *testing.T) {
+	ctx := context.Background()
+
+	var expired int32
+	l, err := NewWithOptions(Options{
+		Options: core.Options{
+			MaxCost: 64,
+			OnEvict: func(key interface{}, value interface{}, reason core.EvictReason) {
+				if reason == core.EvictReasonExpired {
+					atomic.AddInt32(&expired, 1)
+				}
+			},
+		},
+		CleanupInterval: 10 * time.Millisecond,
+		Shards:          4,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 100; i++ {
+		l.Add(ctx, i, []byte("aaaa"), 20*time.Millisecond)
+	}
+	if l.Cost(ctx) > 64 {
+		t.Errorf("bad cost: %v", l.Cost(ctx))
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if l.Len(ctx) != 0 || atomic.LoadInt32(&expired) == 0 {
+		t.Errorf("keys should have been expired by the janitor: %v", l.Len(ctx))
+	}
+}
+
+func TestShardedConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := NewSharded(0, 1024)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("%d-%d", g, i%100)
+				l.Add(ctx, key, i, 0)
+				if _, ok := l.Get(ctx, key); !ok {
+					t.Errorf("%s should have been added", key)
+				}
+				if i%10 == 0 {
+					l.Remove(ctx, key)
+					l.Len(ctx)
+				}
+			}
+		}(g)
+		l.AddHook(&countHook{})
+	}
+	wg.Wait()
+
+	if l.Len(ctx) > 800 {
+		t.Errorf("bad len: %v", l.Len(ctx))
+	}
+}
+
+func benchmarkParallel(b *testing.B, l Client) {
+	ctx := context.Background()
+
+	keys := make([]string, 4096)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+		l.Add(ctx, keys[i], i, 0)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i%len(keys)]
+			if i%10 == 0 {
+				l.Add(ctx, key, i, 0)
+			} else {
+				l.Get(ctx, key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkLRU_Parallel(b *testing.B) {
+	l, err := New(8192)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	benchmarkParallel(b, l)
+}
+
+func BenchmarkSharded_Parallel(b *testing.B) {
+	l, err := NewSharded(0, 8192)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	benchmarkParallel(b, l)
+}
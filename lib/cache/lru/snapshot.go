@@ -0,0 +1,299 @@
+package lru
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/1infras/go-kit/lib/cache/lru/core"
+)
+
+// A snapshot starts with the magic, the version, the time it is taken in unix nanoseconds and the number of entries.
+// Every entry is the key, the value, the remaining expiration and the remaining soft expiration in nanoseconds,
+// 0 means no expiration. Keys and values are written with a tag of their type followed by their content.
+const (
+	snapshotMagic   = "LRUS"
+	snapshotVersion = 1
+	// maxSnapshotLength bounds the length of a key or a value so a corrupted snapshot does not allocate too much
+	maxSnapshotLength = 1 << 30
+)
+
+// ErrSnapshotFormat is returned by Restore when the input is not a snapshot of a supported version
+var ErrSnapshotFormat = errors.New("lru: invalid snapshot format")
+
+const (
+	tagString byte = iota + 1
+	tagBytes
+	tagBool
+	tagInt
+	tagInt8
+	tagInt16
+	tagInt32
+	tagInt64
+	tagUint
+	tagUint8
+	tagUint16
+	tagUint32
+	tagUint64
+	tagFloat32
+	tagFloat64
+)
+
+// writeSnapshot writes entries to w, keys and values must be strings, []byte, booleans or numbers
+func writeSnapshot(w io.Writer, entries []core.Entry) error {
+	now := time.Now()
+	buf := bufio.NewWriter(w)
+	e := &encoder{w: buf}
+
+	e.raw([]byte(snapshotMagic))
+	e.raw([]byte{snapshotVersion})
+	e.varint(now.UnixNano())
+	e.uvarint(uint64(len(entries)))
+
+	for _, entry := range entries {
+		if err := e.value(entry.Key); err != nil {
+			return err
+		}
+		if err := e.value(entry.Value); err != nil {
+			return err
+		}
+		e.varint(int64(remaining(now, entry.Expire)))
+		e.varint(int64(remaining(now, entry.SoftExpire)))
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return buf.Flush()
+}
+
+// readSnapshot reads entries from r and calls add for every entry is not expired with its remaining expirations,
+// the time passed since the snapshot is taken is deducted from them
+func readSnapshot(r io.Reader, add func(key, value interface{}, softExpiration, expiration time.Duration)) (restored int, err error) {
+	d := &decoder{r: bufio.NewReader(r)}
+
+	header := d.raw(len(snapshotMagic) + 1)
+	if d.err != nil || string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return 0, ErrSnapshotFormat
+	}
+	if header[len(snapshotMagic)] != snapshotVersion {
+		return 0, fmt.Errorf("%w: version %d is not supported", ErrSnapshotFormat, header[len(snapshotMagic)])
+	}
+
+	takenAt := time.Unix(0, d.varint())
+	elapsed := time.Since(takenAt)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	count := d.uvarint()
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		key := d.value()
+		value := d.value()
+		expiration := time.Duration(d.varint())
+		softExpiration := time.Duration(d.varint())
+		if d.err != nil {
+			break
+		}
+
+		if expiration > 0 {
+			if expiration -= elapsed; expiration <= 0 {
+				continue
+			}
+		}
+		if softExpiration > 0 {
+			// A stale entry stays stale
+			if softExpiration -= elapsed; softExpiration <= 0 {
+				softExpiration = time.Nanosecond
+			}
+		}
+
+		add(key, value, softExpiration, expiration)
+		restored++
+	}
+
+	if d.err != nil {
+		return restored, fmt.Errorf("%w: %v", ErrSnapshotFormat, d.err)
+	}
+	return restored, nil
+}
+
+// remaining is the duration from now until t, 0 means no expiration
+func remaining(now, t time.Time) time.Duration {
+	if t.IsZero() {
+		return 0
+	}
+	if d := t.Sub(now); d > 0 {
+		return d
+	}
+	return time.Nanosecond
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (_this *encoder) raw(b []byte) {
+	if _this.err != nil {
+		return
+	}
+	_, _this.err = _this.w.Write(b)
+}
+
+func (_this *encoder) uvarint(v uint64) {
+	_this.raw(_this.buf[:binary.PutUvarint(_this.buf[:], v)])
+}
+
+func (_this *encoder) varint(v int64) {
+	_this.raw(_this.buf[:binary.PutVarint(_this.buf[:], v)])
+}
+
+func (_this *encoder) bytes(tag byte, b []byte) {
+	_this.raw([]byte{tag})
+	_this.uvarint(uint64(len(b)))
+	_this.raw(b)
+}
+
+func (_this *encoder) signed(tag byte, v int64) {
+	_this.raw([]byte{tag})
+	_this.varint(v)
+}
+
+func (_this *encoder) unsigned(tag byte, v uint64) {
+	_this.raw([]byte{tag})
+	_this.uvarint(v)
+}
+
+func (_this *encoder) value(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		_this.bytes(tagString, []byte(v))
+	case []byte:
+		_this.bytes(tagBytes, v)
+	case bool:
+		if v {
+			_this.unsigned(tagBool, 1)
+		} else {
+			_this.unsigned(tagBool, 0)
+		}
+	case int:
+		_this.signed(tagInt, int64(v))
+	case int8:
+		_this.signed(tagInt8, int64(v))
+	case int16:
+		_this.signed(tagInt16, int64(v))
+	case int32:
+		_this.signed(tagInt32, int64(v))
+	case int64:
+		_this.signed(tagInt64, v)
+	case uint:
+		_this.unsigned(tagUint, uint64(v))
+	case uint8:
+		_this.unsigned(tagUint8, uint64(v))
+	case uint16:
+		_this.unsigned(tagUint16, uint64(v))
+	case uint32:
+		_this.unsigned(tagUint32, uint64(v))
+	case uint64:
+		_this.unsigned(tagUint64, v)
+	case float32:
+		_this.unsigned(tagFloat32, uint64(math.Float32bits(v)))
+	case float64:
+		_this.unsigned(tagFloat64, math.Float64bits(v))
+	default:
+		return fmt.Errorf("lru: snapshot does not support %T", v)
+	}
+	return nil
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (_this *decoder) raw(n int) []byte {
+	if _this.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	_, _this.err = io.ReadFull(_this.r, b)
+	return b
+}
+
+func (_this *decoder) uvarint() uint64 {
+	if _this.err != nil {
+		return 0
+	}
+	var v uint64
+	v, _this.err = binary.ReadUvarint(_this.r)
+	return v
+}
+
+func (_this *decoder) varint() int64 {
+	if _this.err != nil {
+		return 0
+	}
+	var v int64
+	v, _this.err = binary.ReadVarint(_this.r)
+	return v
+}
+
+func (_this *decoder) bytes() []byte {
+	n := _this.uvarint()
+	if _this.err == nil && n > maxSnapshotLength {
+		_this.err = fmt.Errorf("length %d is too large", n)
+	}
+	return _this.raw(int(n))
+}
+
+func (_this *decoder) value() interface{} {
+	if _this.err != nil {
+		return nil
+	}
+	var tag byte
+	if tag, _this.err = _this.r.ReadByte(); _this.err != nil {
+		return nil
+	}
+
+	switch tag {
+	case tagString:
+		return string(_this.bytes())
+	case tagBytes:
+		return _this.bytes()
+	case tagBool:
+		return _this.uvarint() == 1
+	case tagInt:
+		return int(_this.varint())
+	case tagInt8:
+		return int8(_this.varint())
+	case tagInt16:
+		return int16(_this.varint())
+	case tagInt32:
+		return int32(_this.varint())
+	case tagInt64:
+		return _this.varint()
+	case tagUint:
+		return uint(_this.uvarint())
+	case tagUint8:
+		return uint8(_this.uvarint())
+	case tagUint16:
+		return uint16(_this.uvarint())
+	case tagUint32:
+		return uint32(_this.uvarint())
+	case tagUint64:
+		return _this.uvarint()
+	case tagFloat32:
+		return math.Float32frombits(uint32(_this.uvarint()))
+	case tagFloat64:
+		return math.Float64frombits(_this.uvarint())
+	default:
+		_this.err = fmt.Errorf("unknown tag %d", tag)
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package lru
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1infras/go-kit/lib/cache/lru/core"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	values := map[interface{}]interface{}{
+		"string":   "a",
+		"bytes":    []byte("b"),
+		"empty":    []byte{},
+		1:          true,
+		int8(-2):   int16(-3),
+		int32(-4):  int64(-5),
+		uint(6):    uint8(7),
+		uint16(8):  uint32(9),
+		uint64(10): float32(1.5),
+		"float64":  float64(-2.5),
+	}
+
+	l, err := New(100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for key, value := range values {
+		l.Add(ctx, key, value, 0)
+	}
+	l.Add(ctx, "expiring", "c", time.Hour)
+	l.AddWithSoftExpiration(ctx, "stale", "d", time.Nanosecond, time.Hour)
+	l.Add(ctx, "expired", "e", time.Nanosecond)
+	l.Get(ctx, "string")
+
+	var buf bytes.Buffer
+	if err := l.Snapshot(ctx, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	restoredLRU, err := New(100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restored, err := restoredLRU.Restore(ctx, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if restored != len(values)+2 {
+		t.Errorf("bad restored: %v", restored)
+	}
+	if !reflect.DeepEqual(l.Keys(ctx), restoredLRU.Keys(ctx)) {
+		t.Errorf("the order should have been kept: %v %v", l.Keys(ctx), restoredLRU.Keys(ctx))
+	}
+	for key, value := range values {
+		if v, ok := restoredLRU.Peek(ctx, key); !ok || !reflect.DeepEqual(v, value) {
+			t.Errorf("bad value of %v: %#v", key, v)
+		}
+	}
+	if _, stale, ok := restoredLRU.GetWithStale(ctx, "stale"); !ok || !stale {
+		t.Errorf("stale should have been restored as stale")
+	}
+	if _, ok := restoredLRU.Peek(ctx, "expired"); ok {
+		t.Errorf("expired should not have been restored")
+	}
+
+	// Entries are restored with their remaining expiration to a sharded LRU
+	sharded, err := NewSharded(4, 100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := sharded.Restore(ctx, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sharded.Len(ctx) != len(values)+2 {
+		t.Errorf("bad len: %v", sharded.Len(ctx))
+	}
+	var shardedBuf bytes.Buffer
+	if err := sharded.Snapshot(ctx, &shardedBuf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if restored, err := restoredLRU.Restore(ctx, &shardedBuf); err != nil || restored != len(values)+2 {
+		t.Errorf("bad restored from sharded: %v %v", restored, err)
+	}
+}
+
+func TestSnapshotExpiration(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := New(10)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(ctx, "short", 1, 50*time.Millisecond)
+	l.Add(ctx, "long", 2, time.Hour)
+
+	var buf bytes.Buffer
+	if err := l.Snapshot(ctx, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The time passed since the snapshot is deducted from the remaining expiration
+	time.Sleep(60 * time.Millisecond)
+
+	restoredLRU, err := New(10)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restored, err := restoredLRU.Restore(ctx, &buf)
+	if err != nil || restored != 1 {
+		t.Fatalf("bad restored: %v %v", restored, err)
+	}
+	if !restoredLRU.Contains(ctx, "long") {
+		t.Errorf("long should have been restored")
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := NewWithOptions(Options{Options: core.Options{Size: 10}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(ctx, "a", struct{}{}, 0)
+	if err := l.Snapshot(ctx, &bytes.Buffer{}); err == nil {
+		t.Errorf("should have an error with an unsupported value")
+	}
+	l.Purge(ctx)
+
+	l.Add(ctx, "a", "a", 0)
+	var buf bytes.Buffer
+	if err := l.Snapshot(ctx, &buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b := buf.Bytes()
+
+	if _, err := l.Restore(ctx, bytes.NewReader([]byte("not a snapshot"))); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("should have a format error: %v", err)
+	}
+	if _, err := l.Restore(ctx, bytes.NewReader(b[:len(b)-1])); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("should have a format error with a truncated snapshot: %v", err)
+	}
+
+	newer := append([]byte{}, b...)
+	newer[len(snapshotMagic)] = snapshotVersion + 1
+	if _, err := l.Restore(ctx, bytes.NewReader(newer)); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("should have a format error with another version: %v", err)
+	}
+}
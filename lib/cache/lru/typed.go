@@ -0,0 +1,205 @@
+package lru
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/1infras/go-kit/lib/hook/common"
+)
+
+// Typed is a Client with keys of type K and values of type V, values which are not V are treated as missed
+type Typed[K comparable, V any] interface {
+	Purge(ctx context.Context)
+	Add(ctx context.Context, key K, value V, expiration time.Duration) (evicted bool)
+	AddWithSoftExpiration(ctx context.Context, key K, value V, softExpiration, expiration time.Duration) (evicted bool)
+	Get(ctx context.Context, key K) (value V, ok bool)
+	GetWithStale(ctx context.Context, key K) (value V, stale, ok bool)
+	Contains(ctx context.Context, key K) bool
+	Peek(ctx context.Context, key K) (value V, ok bool)
+	ContainsOrAdd(ctx context.Context, key K, value V, expiration time.Duration) (ok, evicted bool)
+	PeekOrAdd(ctx context.Context, key K, value V, expiration time.Duration) (previous V, ok, evicted bool)
+	Remove(ctx context.Context, key K) (present bool)
+	Resize(ctx context.Context, size int) (evicted int)
+	RemoveOldest(ctx context.Context) (key K, value V, ok bool)
+	GetOldest(ctx context.Context) (key K, value V, ok bool)
+	Keys(ctx context.Context) []K
+	Len(ctx context.Context) int
+	Cost(ctx context.Context) int64
+	RemoveExpired(ctx context.Context) (removed int)
+	Snapshot(ctx context.Context, w io.Writer) error
+	Restore(ctx context.Context, r io.Reader) (restored int, err error)
+	Subscribe(handler EventHandler) (unsubscribe func())
+	AddHook(hook common.HookProcess)
+	AddOperationHook(hook common.OperationHook)
+	AddInterceptor(interceptor common.Interceptor)
+	Close()
+	// Client returns the underlying untyped client
+	Client() Client
+}
+
+type typed[K comparable, V any] struct {
+	client Client
+}
+
+// NewTyped wraps client with keys of type K and values of type V, hooks of client are kept
+func NewTyped[K comparable, V any](client Client) Typed[K, V] {
+	return &typed[K, V]{client: client}
+}
+
+// cast converts value to V, ok is false when value is not V
+func cast[V any](value interface{}, found bool) (v V, ok bool) {
+	if !found {
+		return v, false
+	}
+	v, ok = value.(V)
+	return v, ok
+}
+
+func (_this *typed[K, V]) Client() Client {
+	return _this.client
+}
+
+// Close stops the janitor.
+func (_this *typed[K, V]) Close() {
+	_this.client.Close()
+}
+
+// AddHook is used wrap Processing before and after for a Process
+func (_this *typed[K, V]) AddHook(hook common.HookProcess) {
+	_this.client.AddHook(hook)
+}
+
+// AddOperationHook adds a hook which sees the key and the start time of every operation
+func (_this *typed[K, V]) AddOperationHook(hook common.OperationHook) {
+	_this.client.AddOperationHook(hook)
+}
+
+// AddInterceptor adds an interceptor wraps every operation
+func (_this *typed[K, V]) AddInterceptor(interceptor common.Interceptor) {
+	_this.client.AddInterceptor(interceptor)
+}
+
+// Purge is used to completely clear the cache.
+func (_this *typed[K, V]) Purge(ctx context.Context) {
+	_this.client.Purge(ctx)
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (_this *typed[K, V]) Add(ctx context.Context, key K, value V, expiration time.Duration) bool {
+	return _this.client.Add(ctx, key, value, expiration)
+}
+
+// AddWithSoftExpiration adds a value to the cache which becomes stale after softExpiration.
+// Returns true if an eviction occurred.
+func (_this *typed[K, V]) AddWithSoftExpiration(ctx context.Context, key K, value V, softExpiration, expiration time.Duration) bool {
+	return _this.client.AddWithSoftExpiration(ctx, key, value, softExpiration, expiration)
+}
+
+// Get looks up a key's value from the cache.
+func (_this *typed[K, V]) Get(ctx context.Context, key K) (V, bool) {
+	return cast[V](_this.client.Get(ctx, key))
+}
+
+// GetWithStale looks up a key's value from the cache and reports whether it is stale.
+func (_this *typed[K, V]) GetWithStale(ctx context.Context, key K) (value V, stale, ok bool) {
+	raw, stale, ok := _this.client.GetWithStale(ctx, key)
+	value, ok = cast[V](raw, ok)
+	return value, stale, ok
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (_this *typed[K, V]) Contains(ctx context.Context, key K) bool {
+	return _this.client.Contains(ctx, key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (_this *typed[K, V]) Peek(ctx context.Context, key K) (V, bool) {
+	return cast[V](_this.client.Peek(ctx, key))
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns whether found and whether an eviction occurred.
+func (_this *typed[K, V]) ContainsOrAdd(ctx context.Context, key K, value V, expiration time.Duration) (ok, evicted bool) {
+	return _this.client.ContainsOrAdd(ctx, key, value, expiration)
+}
+
+// PeekOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns whether found and whether an eviction occurred.
+func (_this *typed[K, V]) PeekOrAdd(ctx context.Context, key K, value V, expiration time.Duration) (previous V, ok, evicted bool) {
+	raw, ok, evicted := _this.client.PeekOrAdd(ctx, key, value, expiration)
+	previous, _ = cast[V](raw, ok)
+	return previous, ok, evicted
+}
+
+// Remove removes the provided key from the cache.
+func (_this *typed[K, V]) Remove(ctx context.Context, key K) bool {
+	return _this.client.Remove(ctx, key)
+}
+
+// Resize changes the cache size.
+func (_this *typed[K, V]) Resize(ctx context.Context, size int) int {
+	return _this.client.Resize(ctx, size)
+}
+
+// RemoveOldest removes the oldest item from the cache.
+func (_this *typed[K, V]) RemoveOldest(ctx context.Context) (key K, value V, ok bool) {
+	rawKey, rawValue, ok := _this.client.RemoveOldest(ctx)
+	key, _ = cast[K](rawKey, ok)
+	value, _ = cast[V](rawValue, ok)
+	return key, value, ok
+}
+
+// GetOldest returns the oldest entry
+func (_this *typed[K, V]) GetOldest(ctx context.Context) (key K, value V, ok bool) {
+	rawKey, rawValue, ok := _this.client.GetOldest(ctx)
+	key, _ = cast[K](rawKey, ok)
+	value, _ = cast[V](rawValue, ok)
+	return key, value, ok
+}
+
+// Keys returns a slice of the keys in the cache which are not expired, keys which are not K are skipped.
+func (_this *typed[K, V]) Keys(ctx context.Context) []K {
+	raw := _this.client.Keys(ctx)
+	keys := make([]K, 0, len(raw))
+	for _, k := range raw {
+		if key, ok := k.(K); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache which are not expired.
+func (_this *typed[K, V]) Len(ctx context.Context) int {
+	return _this.client.Len(ctx)
+}
+
+// Cost returns the total cost of entries in the cache.
+func (_this *typed[K, V]) Cost(ctx context.Context) int64 {
+	return _this.client.Cost(ctx)
+}
+
+// RemoveExpired removes every expired item from the cache.
+func (_this *typed[K, V]) RemoveExpired(ctx context.Context) int {
+	return _this.client.RemoveExpired(ctx)
+}
+
+// Snapshot writes entries which are not expired with their remaining expiration to w.
+func (_this *typed[K, V]) Snapshot(ctx context.Context, w io.Writer) error {
+	return _this.client.Snapshot(ctx, w)
+}
+
+// Restore adds entries of a snapshot which are not expired yet.
+func (_this *typed[K, V]) Restore(ctx context.Context, r io.Reader) (int, error) {
+	return _this.client.Restore(ctx, r)
+}
+
+// Subscribe calls handler for every event of the cache until unsubscribe is called, keys and values of events are not typed
+func (_this *typed[K, V]) Subscribe(handler EventHandler) func() {
+	return _this.client.Subscribe(handler)
+}
@@ -0,0 +1,53 @@
+package lru
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTyped(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	hook := &countHook{}
+	client.AddHook(hook)
+
+	l := NewTyped[string, []byte](client)
+
+	l.Add(ctx, "a", []byte("1"), 0)
+	l.Add(ctx, "b", []byte("2"), 0)
+	if v, ok := l.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("bad value of a: %v", v)
+	}
+	if previous, ok, _ := l.PeekOrAdd(ctx, "b", []byte("3"), 0); !ok || string(previous) != "2" {
+		t.Errorf("bad previous of b: %v", previous)
+	}
+	if key, value, ok := l.GetOldest(ctx); !ok || key != "b" || string(value) != "2" {
+		t.Errorf("bad oldest: %v %v", key, value)
+	}
+	if keys := l.Keys(ctx); len(keys) != 2 || keys[0] != "b" || keys[1] != "a" {
+		t.Errorf("bad keys: %v", keys)
+	}
+
+	// Values of another type added to the client are missed
+	client.Add(ctx, "c", 3, 0)
+	if _, ok := l.Get(ctx, "c"); ok {
+		t.Errorf("c should not be a []byte")
+	}
+	if !l.Contains(ctx, "c") {
+		t.Errorf("c should have been contained")
+	}
+
+	if key, _, ok := l.RemoveOldest(ctx); !ok || key != "a" {
+		t.Errorf("a should have been the oldest: %v", key)
+	}
+	if l.Len(ctx) != 1 || l.Client() != client {
+		t.Errorf("bad len: %v", l.Len(ctx))
+	}
+	if hook.calls == 0 {
+		t.Errorf("hooks of client should have been kept")
+	}
+}
@@ -0,0 +1,143 @@
+package onecache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/1infras/go-kit/logger"
+)
+
+func (_this *Client) mget(ctx context.Context, keys []string) (map[string]Element, error) {
+	defer func() {
+		atomic.AddUint32(&_this.stat.totalReads, uint32(len(keys)))
+		atomic.AddUint32(&_this.stat.totalOperations, uint32(len(keys)))
+	}()
+
+	elements := make(map[string]Element, len(keys))
+	var missed []string
+
+	for _, key := range keys {
+		if _, ok := elements[key]; ok {
+			continue
+		}
+		b, stale, existed := _this.lru.GetWithStale(ctx, key)
+		if !existed {
+			missed = append(missed, key)
+			continue
+		}
+		if isNegative(b) {
+			atomic.AddUint32(&_this.stat.totalNegativeHits, 1)
+			continue
+		}
+		if stale {
+			_this.refresh(key)
+		}
+		elements[key] = _this.newElement(b, stale)
+		atomic.AddUint32(&_this.stat.totalLocalHits, 1)
+		atomic.AddInt64(&_this.stat.totalReadBytes, int64(len(b)))
+	}
+
+	if len(missed) == 0 {
+		return elements, nil
+	}
+
+	if !_this.remoteCache {
+		atomic.AddUint32(&_this.stat.totalMisses, uint32(len(missed)))
+		return elements, nil
+	}
+
+	remoteKeys := make([]string, len(missed))
+	for i, key := range missed {
+		remoteKeys[i] = _this.getRemoteKey(key)
+	}
+
+	values, err := _this.remote.MGet(ctx, remoteKeys...)
+	if err != nil {
+		atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+		logger.Warn("get multiple remote cache has error", zap.String("namespace", _this.namespace), zap.String("error", err.Error()))
+	}
+
+	for i, key := range remoteKeys {
+		b, ok := values[key]
+		if !ok {
+			atomic.AddUint32(&_this.stat.totalMisses, 1)
+			continue
+		}
+		b, stale := _this.unwrap(b)
+		if isNegative(b) {
+			atomic.AddUint32(&_this.stat.totalNegativeHits, 1)
+			continue
+		}
+		if stale {
+			_this.refresh(missed[i])
+		}
+		elements[missed[i]] = _this.newElement(b, stale)
+		atomic.AddUint32(&_this.stat.totalRemoteHits, 1)
+		atomic.AddInt64(&_this.stat.totalReadBytes, int64(len(b)))
+	}
+
+	return elements, nil
+}
+
+func (_this *Client) mset(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	encoded := make(map[string][]byte, len(values))
+	for key, value := range values {
+		b, err := _this.serializer.Encode(value)
+		if err != nil {
+			return fmt.Errorf("encode value of key %s has error: %v", key, err)
+		}
+		encoded[key] = b
+	}
+
+	for key, b := range encoded {
+		_this.setBytes(ctx, key, b, expiration)
+		atomic.AddUint32(&_this.stat.totalWrites, 1)
+		atomic.AddUint32(&_this.stat.totalOperations, 1)
+	}
+
+	return nil
+}
+
+func (_this *Client) mdelete(ctx context.Context, keys []string) {
+	defer atomic.AddUint32(&_this.stat.totalOperations, uint32(len(keys)))
+
+	for _, key := range keys {
+		_this.remove(ctx, key)
+	}
+}
+
+// MGet gets multiple keys, keys are served from LRU first and the remainder is resolved with a single MGet of the remote store.
+// Keys are missed in every layer are not present in the result
+func (_this *Client) MGet(ctx context.Context, keys ...string) (elements map[string]Element, err error) {
+	err = _this.process(ctx, "mget", nil, func(ctx context.Context) (err error) {
+		elements, err = _this.mget(ctx, keys)
+		return
+	})
+
+	return
+}
+
+// MSet sets multiple keys with the same expiration, remote cache is written with a single batch
+func (_this *Client) MSet(ctx context.Context, values map[string]interface{}, expiration int) (err error) {
+	if expiration < 0 {
+		expiration = -1
+	}
+
+	err = _this.process(ctx, "mset", nil, func(ctx context.Context) error {
+		return _this.mset(ctx, values, time.Second*time.Duration(expiration))
+	})
+
+	return
+}
+
+// MDelete deletes multiple keys, remote cache is written with a single batch
+func (_this *Client) MDelete(ctx context.Context, keys ...string) {
+	_ = _this.process(ctx, "mdelete", nil, func(ctx context.Context) error {
+		_this.mdelete(ctx, keys)
+		return nil
+	})
+}
@@ -0,0 +1,100 @@
+package onecache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector exports the statistics of OneCaches labelled by namespace
+type Collector struct {
+	caches []OneCache
+
+	operations     *prometheus.Desc
+	reads          *prometheus.Desc
+	writes         *prometheus.Desc
+	hits           *prometheus.Desc
+	misses         *prometheus.Desc
+	negativeHits   *prometheus.Desc
+	hitRatio       *prometheus.Desc
+	readBytes      *prometheus.Desc
+	writeBytes     *prometheus.Desc
+	evictions      *prometheus.Desc
+	expirations    *prometheus.Desc
+	remoteErrors   *prometheus.Desc
+	droppedWrites  *prometheus.Desc
+	syncQueueDepth *prometheus.Desc
+	localItems     *prometheus.Desc
+	localBytes     *prometheus.Desc
+}
+
+// NewCollector returns a Collector for caches, namespaces of caches must be unique
+func NewCollector(caches ...OneCache) *Collector {
+	namespace := []string{"namespace"}
+	layer := []string{"namespace", "layer"}
+
+	return &Collector{
+		caches: caches,
+
+		operations:     prometheus.NewDesc("onecache_operations_total", "Total number of operations.", namespace, nil),
+		reads:          prometheus.NewDesc("onecache_reads_total", "Total number of read operations.", namespace, nil),
+		writes:         prometheus.NewDesc("onecache_writes_total", "Total number of write operations.", namespace, nil),
+		hits:           prometheus.NewDesc("onecache_hits_total", "Total number of hits by layer.", layer, nil),
+		misses:         prometheus.NewDesc("onecache_misses_total", "Total number of misses.", namespace, nil),
+		negativeHits:   prometheus.NewDesc("onecache_negative_hits_total", "Total number of reads served by a cached not found entry.", namespace, nil),
+		hitRatio:       prometheus.NewDesc("onecache_hit_ratio", "Share of reads served by layer.", layer, nil),
+		readBytes:      prometheus.NewDesc("onecache_read_bytes_total", "Total number of bytes read.", namespace, nil),
+		writeBytes:     prometheus.NewDesc("onecache_write_bytes_total", "Total number of bytes written.", namespace, nil),
+		evictions:      prometheus.NewDesc("onecache_evictions_total", "Total number of LRU evictions.", namespace, nil),
+		expirations:    prometheus.NewDesc("onecache_expirations_total", "Total number of LRU items removed after they expired.", namespace, nil),
+		remoteErrors:   prometheus.NewDesc("onecache_remote_errors_total", "Total number of remote cache errors.", namespace, nil),
+		droppedWrites:  prometheus.NewDesc("onecache_dropped_writes_total", "Total number of remote writes dropped by the sync queue.", namespace, nil),
+		syncQueueDepth: prometheus.NewDesc("onecache_sync_queue_depth", "Number of writes waiting to be synced to the remote cache.", namespace, nil),
+		localItems:     prometheus.NewDesc("onecache_local_items", "Number of items in LRU.", namespace, nil),
+		localBytes:     prometheus.NewDesc("onecache_local_bytes", "Total length of values in LRU.", namespace, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (_this *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- _this.operations
+	ch <- _this.reads
+	ch <- _this.writes
+	ch <- _this.hits
+	ch <- _this.misses
+	ch <- _this.negativeHits
+	ch <- _this.hitRatio
+	ch <- _this.readBytes
+	ch <- _this.writeBytes
+	ch <- _this.evictions
+	ch <- _this.expirations
+	ch <- _this.remoteErrors
+	ch <- _this.droppedWrites
+	ch <- _this.syncQueueDepth
+	ch <- _this.localItems
+	ch <- _this.localBytes
+}
+
+// Collect implements prometheus.Collector
+func (_this *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range _this.caches {
+		s := c.Stats()
+
+		ch <- prometheus.MustNewConstMetric(_this.operations, prometheus.CounterValue, float64(s.Operations), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.reads, prometheus.CounterValue, float64(s.Reads), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.writes, prometheus.CounterValue, float64(s.Writes), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.hits, prometheus.CounterValue, float64(s.LocalHits), s.Namespace, "local")
+		ch <- prometheus.MustNewConstMetric(_this.hits, prometheus.CounterValue, float64(s.RemoteHits), s.Namespace, "remote")
+		ch <- prometheus.MustNewConstMetric(_this.misses, prometheus.CounterValue, float64(s.Misses), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.negativeHits, prometheus.CounterValue, float64(s.NegativeHits), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.hitRatio, prometheus.GaugeValue, s.LocalHitRatio, s.Namespace, "local")
+		ch <- prometheus.MustNewConstMetric(_this.hitRatio, prometheus.GaugeValue, s.RemoteHitRatio, s.Namespace, "remote")
+		ch <- prometheus.MustNewConstMetric(_this.readBytes, prometheus.CounterValue, float64(s.ReadBytes), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.writeBytes, prometheus.CounterValue, float64(s.WriteBytes), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.evictions, prometheus.CounterValue, float64(s.Evictions), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.expirations, prometheus.CounterValue, float64(s.Expirations), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.remoteErrors, prometheus.CounterValue, float64(s.RemoteErrors), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.droppedWrites, prometheus.CounterValue, float64(s.DroppedWrites), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.syncQueueDepth, prometheus.GaugeValue, float64(s.SyncQueueDepth), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.localItems, prometheus.GaugeValue, float64(s.LocalItems), s.Namespace)
+		ch <- prometheus.MustNewConstMetric(_this.localBytes, prometheus.GaugeValue, float64(s.LocalBytes), s.Namespace)
+	}
+}
@@ -0,0 +1,120 @@
+package onecache
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/1infras/go-kit/lib/cache/internal/events"
+	"github.com/1infras/go-kit/lib/cache/lru"
+	"github.com/1infras/go-kit/lib/cache/lru/core"
+)
+
+// EventType tells what happened to a key of the cache
+type EventType int
+
+const (
+	// EventAdd is used when a key is added to LRU
+	EventAdd EventType = iota
+	// EventUpdate is used when the value of a key in LRU is replaced
+	EventUpdate
+	// EventEvict is used when a key is evicted from LRU to respect max items or max bytes
+	EventEvict
+	// EventExpire is used when an expired key is removed from LRU
+	EventExpire
+	// EventRemove is used when a key is removed from LRU explicitly, by an invalidation or a flush
+	EventRemove
+	// EventRemoteSyncFailure is used when a write of a key can not be synced to the remote cache
+	EventRemoteSyncFailure
+)
+
+func (_this EventType) String() string {
+	switch _this {
+	case EventAdd:
+		return "add"
+	case EventUpdate:
+		return "update"
+	case EventEvict:
+		return "evict"
+	case EventExpire:
+		return "expire"
+	case EventRemove:
+		return "remove"
+	case EventRemoteSyncFailure:
+		return "remote_sync_failure"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrWriteDropped is the error of EventRemoteSyncFailure when a write is dropped because the cache is closed or the sync queue is full
+var ErrWriteDropped = errors.New("onecache: remote write is dropped")
+
+// Event is sent to subscribers of a cache
+type Event struct {
+	Type      EventType
+	Namespace string
+	Key       string
+	// Value is the encoded value of the key, it is not set for EventRemoteSyncFailure
+	Value []byte
+	// Reason is why the key has left LRU, it is only set for EventEvict, EventExpire and EventRemove
+	Reason core.EvictReason
+	// Err is only set for EventRemoteSyncFailure
+	Err error
+}
+
+// EventHandler is called for every event, events of LRU are delivered while LRU is locked and remote sync failures
+// are delivered by the sync worker so it must return quickly and must not call the cache
+type EventHandler func(event Event)
+
+// SubscribeChannel subscribes a channel of the given size to events of cache, events are dropped when the channel is full.
+// The channel is closed by unsubscribe
+func SubscribeChannel(cache OneCache, size int) (ch <-chan Event, unsubscribe func()) {
+	return events.SubscribeChannel(cache.Subscribe, size)
+}
+
+// localEvent converts an event of LRU
+func (_this *Client) localEvent(event lru.Event) Event {
+	e := Event{Namespace: _this.namespace, Reason: event.Reason}
+	e.Key, _ = event.Key.(string)
+	e.Value, _ = event.Value.([]byte)
+
+	switch event.Type {
+	case lru.EventAdd:
+		e.Type = EventAdd
+	case lru.EventUpdate:
+		e.Type = EventUpdate
+	case lru.EventEvict:
+		e.Type = EventEvict
+	case lru.EventExpire:
+		e.Type = EventExpire
+	default:
+		e.Type = EventRemove
+	}
+	return e
+}
+
+// syncFailed emits EventRemoteSyncFailure of key
+func (_this *Client) syncFailed(key string, err error) {
+	_this.events.Emit(Event{
+		Type:      EventRemoteSyncFailure,
+		Namespace: _this.namespace,
+		Key:       key,
+		Err:       err,
+	})
+}
+
+// Subscribe calls handler for every event of the cache until unsubscribe is called
+func (_this *Client) Subscribe(handler EventHandler) (unsubscribe func()) {
+	unsubscribeLocal := _this.lru.Subscribe(func(event lru.Event) {
+		handler(_this.localEvent(event))
+	})
+	unsubscribeRemote := _this.events.Subscribe(handler)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unsubscribeLocal()
+			unsubscribeRemote()
+		})
+	}
+}
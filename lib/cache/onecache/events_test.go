@@ -0,0 +1,100 @@
+package onecache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore fails every write
+type failingStore struct {
+	*FakeStore
+}
+
+func (_this *failingStore) Write(ctx context.Context, writes []RemoteWrite) []error {
+	errs := make([]error, len(writes))
+	for i := range errs {
+		errs[i] = errors.New("store is down")
+	}
+	return errs
+}
+
+func TestOneCacheEvents(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetMaxItems(2),
+		SetRemoteCacheNamespace("events"),
+		SetRemoteStore(&failingStore{NewFakeStore()}))
+	assert.Nil(t, err)
+
+	var lock sync.Mutex
+	var local, failures []Event
+	unsubscribe := oneCache.Subscribe(func(event Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		if event.Type == EventRemoteSyncFailure {
+			failures = append(failures, event)
+		} else {
+			local = append(local, event)
+		}
+	})
+
+	assert.Nil(t, oneCache.Set(ctx, "a", 1, -1))
+	assert.Nil(t, oneCache.Set(ctx, "a", 2, -1))
+	assert.Nil(t, oneCache.Set(ctx, "b", 3, -1))
+	assert.Nil(t, oneCache.Set(ctx, "c", 4, -1))
+	oneCache.Delete(ctx, "b")
+	assert.Nil(t, oneCache.Close(ctx))
+
+	lock.Lock()
+	var types []EventType
+	for _, event := range local {
+		types = append(types, event.Type)
+		assert.Equal(t, "events", event.Namespace)
+	}
+	assert.Equal(t, []EventType{EventAdd, EventUpdate, EventAdd, EventEvict, EventAdd, EventRemove}, types)
+	assert.Equal(t, "a", local[3].Key)
+	assert.Equal(t, []byte("2"), local[3].Value)
+
+	assert.Len(t, failures, 5)
+	for _, event := range failures {
+		assert.NotNil(t, event.Err)
+	}
+	lock.Unlock()
+
+	// Writes after close are dropped
+	assert.Nil(t, oneCache.Set(ctx, "d", 5, -1))
+	lock.Lock()
+	assert.Len(t, failures, 6)
+	assert.Equal(t, ErrWriteDropped, failures[5].Err)
+	lock.Unlock()
+
+	unsubscribe()
+	assert.Nil(t, oneCache.Set(ctx, "e", 6, -1))
+	lock.Lock()
+	assert.Len(t, local, 7)
+	lock.Unlock()
+}
+
+func TestSubscribeChannel(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx))
+	assert.Nil(t, err)
+
+	events, unsubscribe := SubscribeChannel(oneCache, 10)
+	assert.Nil(t, oneCache.Set(ctx, "a", 1, -1))
+
+	event := <-events
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, "a", event.Key)
+
+	unsubscribe()
+	_, ok := <-events
+	assert.False(t, ok)
+}
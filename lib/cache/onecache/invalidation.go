@@ -0,0 +1,99 @@
+package onecache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"github.com/1infras/go-kit/logger"
+)
+
+// invalidation is the payload published on the namespace channel whenever a key changes
+type invalidation struct {
+	Source string   `json:"source"`
+	Action int      `json:"action"`
+	Keys   []string `json:"keys,omitempty"`
+}
+
+// SetInvalidation enables publishing and subscribing invalidation messages on the namespace channel,
+// so every instance sharing the remote cache evicts stale keys from its LRU
+func SetInvalidation(enabled bool) ClientOptionFunc {
+	return func(c *Client) error {
+		c.invalidation = enabled
+		return nil
+	}
+}
+
+func newInstanceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%p", b)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (_this *Client) getInvalidationChannel() string {
+	return fmt.Sprintf("%s_invalidation", _this.namespace)
+}
+
+func (_this *Client) publishInvalidation(ctx context.Context, action int, keys ...string) {
+	if !_this.invalidation {
+		return
+	}
+
+	b, err := json.Marshal(&invalidation{
+		Source: _this.id,
+		Action: action,
+		Keys:   keys,
+	})
+	if err != nil {
+		logger.Error("encode invalidation message has error", zap.Strings("keys", keys), zap.String("error", err.Error()))
+		return
+	}
+
+	if err := _this.pubSub.Publish(ctx, _this.getInvalidationChannel(), b); err != nil {
+		atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+		logger.Error("publish invalidation message has error", zap.Strings("keys", keys), zap.String("error", err.Error()))
+	}
+}
+
+func (_this *Client) subscribeInvalidation() error {
+	messages, err := _this.pubSub.Subscribe(_this.context, _this.getInvalidationChannel())
+	if err != nil {
+		return fmt.Errorf("subscribe invalidation channel has error: %v", err)
+	}
+
+	go func(ctx context.Context) {
+		for message := range messages {
+			_this.handleInvalidation(ctx, message)
+		}
+	}(_this.context)
+
+	return nil
+}
+
+func (_this *Client) handleInvalidation(ctx context.Context, payload []byte) {
+	var msg invalidation
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		logger.Warn("decode invalidation message has error", zap.String("namespace", _this.namespace), zap.String("error", err.Error()))
+		return
+	}
+
+	if msg.Source == _this.id {
+		return
+	}
+
+	switch msg.Action {
+	case AddElement, DeleteElement:
+		for _, key := range msg.Keys {
+			_this.lru.Remove(ctx, key)
+		}
+	case FlushElement:
+		_this.lru.Purge(ctx)
+	}
+}
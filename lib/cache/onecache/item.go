@@ -1,6 +1,7 @@
 package onecache
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 )
@@ -8,6 +9,8 @@ import (
 const (
 	AddElement int = iota
 	DeleteElement
+	FlushElement
+	InvalidateTagElement
 )
 
 type Element interface {
@@ -18,17 +21,37 @@ type Element interface {
 	Float64() (float64, error)
 	Boolean() (bool, error)
 	String() string
+	Decode(out interface{}) error
+	Stale() bool
 }
 
 type item struct {
 	key        string
-	value      interface{}
+	value      []byte
 	expiration time.Duration
 	action     int
+	tags       []string
+	// sequence orders the item with writes through the sync queue
+	sequence uint64
 }
 
 type element struct {
-	value []byte
+	value      []byte
+	stale      bool
+	serializer Serializer
+}
+
+// scalar returns the plain text of value, a value was encoded as a string by the serializer is decoded first and
+// a value was compressed by a CompressingSerializer is decompressed
+func (_this *element) scalar() string {
+	var s string
+	if err := _this.Decode(&s); err == nil {
+		return s
+	}
+	if c, ok := _this.serializer.(*CompressingSerializer); ok {
+		return string(c.uncompressed(_this.value))
+	}
+	return string(_this.value)
 }
 
 func (_this *element) Bytes() []byte {
@@ -39,22 +62,52 @@ func (_this *element) Val() interface{} {
 	return _this.value
 }
 
+// Int decodes the value as an int with the serializer, a value was stored as a numeric string is parsed instead
 func (_this *element) Int() (int, error) {
-	return strconv.Atoi(string(_this.value))
+	var v int
+	if err := _this.Decode(&v); err == nil {
+		return v, nil
+	}
+	return strconv.Atoi(_this.scalar())
 }
 
 func (_this *element) Int64() (int64, error) {
-	return strconv.ParseInt(string(_this.value), 10, 64)
+	var v int64
+	if err := _this.Decode(&v); err == nil {
+		return v, nil
+	}
+	return strconv.ParseInt(_this.scalar(), 10, 64)
 }
 
 func (_this *element) Float64() (float64, error) {
-	return strconv.ParseFloat(string(_this.value), 64)
+	var v float64
+	if err := _this.Decode(&v); err == nil {
+		return v, nil
+	}
+	return strconv.ParseFloat(_this.scalar(), 64)
 }
 
 func (_this *element) Boolean() (bool, error) {
-	return strconv.ParseBool(string(_this.value))
+	var v bool
+	if err := _this.Decode(&v); err == nil {
+		return v, nil
+	}
+	return strconv.ParseBool(_this.scalar())
+}
+
+// Stale reports whether the value has passed its soft expiration and is being refreshed
+func (_this *element) Stale() bool {
+	return _this.stale
 }
 
 func (_this *element) String() string {
-	return string(_this.value)
+	return _this.scalar()
+}
+
+// Decode decodes the value into out with the serializer of the client
+func (_this *element) Decode(out interface{}) error {
+	if _this.serializer == nil {
+		return fmt.Errorf("serializer must not be empty")
+	}
+	return _this.serializer.Decode(_this.value, out)
 }
@@ -0,0 +1,102 @@
+package onecache
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	DefaultFlushBatchSize = 500
+)
+
+// KeyIterator iterates over keys of a namespace in the remote cache, keys are returned without the namespace prefix
+type KeyIterator interface {
+	Next(ctx context.Context) bool
+	Key() string
+	Err() error
+}
+
+// prefixIterator strips the namespace prefix from keys of the remote store
+type prefixIterator struct {
+	KeyIterator
+	prefix string
+}
+
+func (_this *prefixIterator) Key() string {
+	return strings.TrimPrefix(_this.KeyIterator.Key(), _this.prefix)
+}
+
+// escapePattern escapes the glob characters of s so it is matched literally by SCAN
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (_this *Client) keys(ctx context.Context, pattern string) KeyIterator {
+	if !_this.remoteCache {
+		return &sliceIterator{}
+	}
+
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	prefix := _this.getRemoteKey("")
+
+	return &prefixIterator{
+		KeyIterator: _this.remote.Scan(ctx, escapePattern(prefix)+pattern),
+		prefix:      prefix,
+	}
+}
+
+// flushRemote deletes every key of the namespace in batches
+func (_this *Client) flushRemote(ctx context.Context) error {
+	iterator := _this.keys(ctx, "*")
+	batch := make([]string, 0, DefaultFlushBatchSize)
+
+	for iterator.Next(ctx) {
+		batch = append(batch, _this.getRemoteKey(iterator.Key()))
+		if len(batch) < DefaultFlushBatchSize {
+			continue
+		}
+		if err := _this.remote.Delete(ctx, batch...); err != nil {
+			atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+			return err
+		}
+		batch = batch[:0]
+	}
+
+	if err := iterator.Err(); err != nil {
+		atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+		return err
+	}
+
+	if len(batch) > 0 {
+		if err := _this.remote.Delete(ctx, batch...); err != nil {
+			atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Keys returns an iterator over keys of the namespace matching pattern in the remote cache,
+// pattern is a SCAN glob is applied after the namespace prefix, an empty pattern matches every key.
+// Sets of tags are hidden
+func (_this *Client) Keys(ctx context.Context, pattern string) (iterator KeyIterator) {
+	_ = _this.process(ctx, "keys", nil, func(ctx context.Context) error {
+		iterator = &tagFilterIterator{_this.keys(ctx, pattern)}
+		return nil
+	})
+
+	return
+}
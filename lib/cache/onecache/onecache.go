@@ -4,14 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 
+	"github.com/1infras/go-kit/lib/cache/internal/events"
 	"github.com/1infras/go-kit/lib/cache/lru"
+	"github.com/1infras/go-kit/lib/cache/lru/core"
 	"github.com/1infras/go-kit/lib/hook/common"
 	"github.com/1infras/go-kit/logger"
 	"github.com/1infras/go-kit/util"
@@ -19,12 +23,26 @@ import (
 
 type OneCache interface {
 	Set(ctx context.Context, key string, value interface{}, expiration int) error
+	SetNotFound(ctx context.Context, key string) error
+	SetWithTags(ctx context.Context, key string, value interface{}, expiration int, tags ...string) error
 	Get(ctx context.Context, key string) (Element, error)
+	GetInto(ctx context.Context, key string, out interface{}) error
+	GetOrLoad(ctx context.Context, key string, expiration int, loader Loader) (Element, error)
 	Contains(ctx context.Context, key string) bool
 	Delete(ctx context.Context, key string)
+	MGet(ctx context.Context, keys ...string) (map[string]Element, error)
+	MSet(ctx context.Context, values map[string]interface{}, expiration int) error
+	MDelete(ctx context.Context, keys ...string)
+	InvalidateTag(ctx context.Context, tag string) error
 	Flush(ctx context.Context) (err error)
+	Keys(ctx context.Context, pattern string) KeyIterator
 	Report(ctx context.Context) (result string)
+	Close(ctx context.Context) error
+	Stats() StatsSnapshot
+	Subscribe(handler EventHandler) (unsubscribe func())
 	AddHook(hook common.HookProcess)
+	AddOperationHook(hook common.OperationHook)
+	AddInterceptor(interceptor common.Interceptor)
 }
 
 const (
@@ -32,36 +50,86 @@ const (
 	DefaultRemoteCache     = false
 	DefaultMaxItems        = 100000
 	DefaultTTL             = 30 * time.Second
+	DefaultLoadTimeout     = 30 * time.Second
 	OptimalInMemAccessTime = 1 * time.Millisecond
 )
 
+// namespaceSeparator follows the namespace in remote keys, namespaces must not contain it so keys of a namespace
+// never share the prefix of another one
+const namespaceSeparator = ":"
+
+// keyLockStripes is the number of locks keys are spread over to order their updates
+const keyLockStripes = 256
+
 var (
 	Nil = errors.New("cache: key is not found")
+	// ErrShortCircuited is returned when an interceptor skips an operation without an error, so the operation has no result
+	ErrShortCircuited = errors.New("onecache: operation is short-circuited by an interceptor")
 )
 
 type ClientOptionFunc func(*Client) error
 
+// Loader is used to load a value from the origin when a key is missed in every layer
+type Loader func(ctx context.Context) (interface{}, error)
+
 // Caching with multiple layers include LRU and Redis
 type Client struct {
-	namespace   string
-	remoteCache bool
-
-	maxItems   int
-	expiration time.Duration
-
-	lru   lru.Client
-	redis redis.UniversalClient
+	id           string
+	namespace    string
+	remoteCache  bool
+	invalidation bool
+
+	maxItems           int
+	maxBytes           int64
+	shards             int
+	cleanupInterval    time.Duration
+	evictionPolicy     core.Policy
+	expiration         time.Duration
+	expirationJitter   time.Duration
+	softExpiration     time.Duration
+	negativeExpiration time.Duration
+	snapshotFile       string
+	snapshotMaxAge     time.Duration
+	loadTimeout        time.Duration
+
+	lru      lru.Typed[string, []byte]
+	remote   RemoteStore
+	pubSub   PubSub
+	tagStore TagStore
 
 	stat       *stat
 	context    context.Context
 	cancelFunc func()
 
-	stream chan item
+	stream         chan item
+	syncQueueSize  int
+	syncBatchSize  int
+	overflowPolicy OverflowPolicy
+	closed         int32
+	closing        chan struct{}
+	drained        chan struct{}
+	syncing        syncState
 
 	serializer Serializer
 
+	// lock is held shared while items are enqueued and exclusively by close, so nothing is enqueued after the sync queue is drained
 	lock sync.RWMutex
+	// keyLocks order updates of a key, LRU and the sync queue see writes of a key in the same order
+	keyLocks [keyLockStripes]sync.Mutex
+
 	hook *common.Hook
+	// attributes of operations passed to hooks, it is shared so hooks must not modify it
+	attributes map[string]interface{}
+	events     events.Subscribers[Event]
+	loader     singleflight.Group
+
+	refresher  Refresher
+	refreshing sync.Map
+
+	// tags and keyTags index tagged keys of LRU
+	tagLock sync.Mutex
+	tags    map[string]map[string]struct{}
+	keyTags map[string][]string
 }
 
 // NewCache
@@ -72,22 +140,32 @@ func NewOneCache(options ...ClientOptionFunc) (OneCache, error) {
 func NewClientWithContext(ctx context.Context, options ...ClientOptionFunc) (OneCache, error) {
 	ctx, cancel := util.GetContextWithCancel(ctx)
 	c := &Client{
+		id:          newInstanceID(),
 		namespace:   DefaultNameSpace,
 		remoteCache: DefaultRemoteCache,
 
-		maxItems:   DefaultMaxItems,
-		expiration: DefaultTTL,
+		maxItems:    DefaultMaxItems,
+		expiration:  DefaultTTL,
+		loadTimeout: DefaultLoadTimeout,
 
 		context:    ctx,
 		cancelFunc: cancel,
 		serializer: &DefaultSerializer{},
 
 		stat: &stat{
-			timeStart: time.Now().Unix(),
+			timeStart: time.Now().UnixNano(),
 		},
 
-		stream: make(chan item),
+		syncQueueSize:  DefaultSyncQueueSize,
+		syncBatchSize:  DefaultSyncBatchSize,
+		overflowPolicy: BlockOnOverflow,
+		closing:        make(chan struct{}),
+		drained:        make(chan struct{}),
+
 		hook: &common.Hook{},
+
+		tags:    make(map[string]map[string]struct{}),
+		keyTags: make(map[string][]string),
 	}
 
 	for _, option := range options {
@@ -96,17 +174,53 @@ func NewClientWithContext(ctx context.Context, options ...ClientOptionFunc) (One
 		}
 	}
 
-	lruCache, err := lru.NewWithExpiration(c.maxItems, c.expiration)
+	c.attributes = map[string]interface{}{common.AttributeNamespace: c.namespace}
+
+	lruCache, err := lru.NewWithOptions(lru.Options{
+		Options: core.Options{
+			Size:    c.maxItems,
+			MaxCost: c.maxBytes,
+			Expire:  c.expiration,
+			OnEvict: c.onEvicted,
+			Policy:  c.evictionPolicy,
+		},
+		CleanupInterval: c.cleanupInterval,
+		Shards:          c.shards,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("onecache: error with init lru cache: %v", err)
 	}
 
-	c.lru = lruCache
+	c.lru = lru.NewTyped[string, []byte](lruCache)
+	c.loadSnapshot(c.context)
+
+	if c.invalidation && !c.remoteCache {
+		return nil, fmt.Errorf("onecache: invalidation requires remote cache")
+	}
+
+	if c.invalidation {
+		pubSub, ok := c.remote.(PubSub)
+		if !ok {
+			return nil, fmt.Errorf("onecache: invalidation requires a remote store supports pub/sub")
+		}
+		c.pubSub = pubSub
+	}
+
+	if c.remoteCache {
+		c.tagStore, _ = c.remote.(TagStore)
+	}
 
 	if c.remoteCache {
+		c.stream = make(chan item, c.syncQueueSize)
 		c.autoSyncRemoteCache()
 	}
 
+	if c.invalidation {
+		if err := c.subscribeInvalidation(); err != nil {
+			return nil, fmt.Errorf("onecache: %v", err)
+		}
+	}
+
 	return c, nil
 }
 
@@ -138,12 +252,22 @@ func SetRemoteCache(redisClient redis.UniversalClient) ClientOptionFunc {
 			return fmt.Errorf("redis client must be be empty")
 		}
 
-		_, err := redisClient.Ping(c.context).Result()
-		if err != nil {
-			return fmt.Errorf("connected to redis client has error: %v", err)
+		return SetRemoteStore(NewRedisStore(redisClient))(c)
+	}
+}
+
+// SetRemoteStore sets the second layer of the cache
+func SetRemoteStore(store RemoteStore) ClientOptionFunc {
+	return func(c *Client) error {
+		if store == nil {
+			return fmt.Errorf("remote store must not be empty")
+		}
+
+		if err := store.Ping(c.context); err != nil {
+			return fmt.Errorf("connected to remote store has error: %v", err)
 		}
 
-		c.redis = redisClient
+		c.remote = store
 		c.remoteCache = true
 		return nil
 	}
@@ -154,6 +278,9 @@ func SetRemoteCacheNamespace(namespace string) ClientOptionFunc {
 		if namespace == "" {
 			return fmt.Errorf("namespace must not be empty")
 		}
+		if strings.Contains(namespace, namespaceSeparator) {
+			return fmt.Errorf("namespace must not contain %q", namespaceSeparator)
+		}
 		c.namespace = namespace
 		return nil
 	}
@@ -169,6 +296,56 @@ func SetMaxItems(maxItems int) ClientOptionFunc {
 	}
 }
 
+// SetMaxBytes bounds LRU by the total length of values in addition to the number of items,
+// the oldest items are evicted when values take more than maxBytes
+func SetMaxBytes(maxBytes int64) ClientOptionFunc {
+	return func(c *Client) error {
+		if maxBytes <= 0 {
+			return fmt.Errorf("max bytes must be greater than 0")
+		}
+		c.maxBytes = maxBytes
+		return nil
+	}
+}
+
+// SetEvictionPolicy sets the policy LRU uses to evict items when it is full, scan resistant policies keep
+// the hot set when many keys are read once. SetMaxBytes is only supported by core.PolicyLRU
+func SetEvictionPolicy(policy core.Policy) ClientOptionFunc {
+	return func(c *Client) error {
+		switch policy {
+		case core.PolicyLRU, core.Policy2Q, core.PolicyARC, core.PolicyTinyLFU:
+			c.evictionPolicy = policy
+			return nil
+		default:
+			return fmt.Errorf("eviction policy %d is not supported", policy)
+		}
+	}
+}
+
+// SetShards splits LRU in shards locked independently to reduce contention under concurrent access, the shards are
+// selected by the hash of keys and every shard evicts its items on its own when it holds maxItems/shards items
+func SetShards(shards int) ClientOptionFunc {
+	return func(c *Client) error {
+		if shards <= 0 {
+			return fmt.Errorf("shards must be greater than 0")
+		}
+		c.shards = shards
+		return nil
+	}
+}
+
+// SetCleanupInterval starts a janitor removes expired items from LRU every interval until the cache is closed,
+// without it expired items are only removed when they are read or evicted
+func SetCleanupInterval(interval time.Duration) ClientOptionFunc {
+	return func(c *Client) error {
+		if interval <= 0 {
+			return fmt.Errorf("cleanup interval must be greater than 0")
+		}
+		c.cleanupInterval = interval
+		return nil
+	}
+}
+
 func SetExpiration(expiration time.Duration) ClientOptionFunc {
 	return func(c *Client) error {
 		if expiration <= 0 {
@@ -179,61 +356,171 @@ func SetExpiration(expiration time.Duration) ClientOptionFunc {
 	}
 }
 
+// SetLoadTimeout bounds loaders called by GetOrLoad, a load is not cancelled by its callers so it is only stopped by the timeout
+func SetLoadTimeout(timeout time.Duration) ClientOptionFunc {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("load timeout must be greater than 0")
+		}
+		c.loadTimeout = timeout
+		return nil
+	}
+}
+
 func (_this *Client) AddHook(hook common.HookProcess) {
 	_this.hook.AddHook(hook)
 }
 
+// AddOperationHook adds a hook which sees the key, the namespace, the start time and the error of every operation,
+// Nil is not an error of operations
+func (_this *Client) AddOperationHook(hook common.OperationHook) {
+	_this.hook.AddOperationHook(hook)
+}
+
+// AddInterceptor adds an interceptor wraps every operation, hooks and interceptors added first are the outermost.
+// Operations short-circuited by an interceptor return zero results with the error of the interceptor, or ErrShortCircuited
+// if the interceptor returns no error
+func (_this *Client) AddInterceptor(interceptor common.Interceptor) {
+	_this.hook.AddInterceptor(interceptor)
+}
+
+// process calls fn with the context passed by the hooks and returns its error or the error of an interceptor,
+// key is nil for operations on many keys
+func (_this *Client) process(ctx context.Context, name string, key interface{}, fn func(ctx context.Context) error) error {
+	called, missed := false, false
+	err := _this.hook.ProcessOperation(ctx, common.Operation{Name: name, Key: key, Attributes: _this.attributes}, func(ctx context.Context) error {
+		called = true
+		err := fn(ctx)
+		if err == Nil {
+			missed = true
+			return nil
+		}
+		return err
+	})
+	if err == nil && !called {
+		return ErrShortCircuited
+	}
+	if err == nil && missed {
+		return Nil
+	}
+	return err
+}
+
+func (_this *Client) newElement(b []byte, stale bool) Element {
+	return &element{
+		value:      b,
+		stale:      stale,
+		serializer: _this.serializer,
+	}
+}
+
 func (_this *Client) getRemoteKey(key string) string {
-	return fmt.Sprintf("%s_%s", _this.namespace, key)
+	return _this.namespace + namespaceSeparator + key
 }
 
-func (_this *Client) set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+func (_this *Client) set(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
 	defer func() {
 		atomic.AddUint32(&_this.stat.totalWrites, 1)
 		atomic.AddUint32(&_this.stat.totalOperations, 1)
 	}()
 
-	if expiration < 0 {
-		expiration = _this.expiration
-	}
-
 	b, err := _this.serializer.Encode(value)
 
 	if err != nil {
 		return fmt.Errorf("encode value has error: %v", err)
 	}
 
-	_this.lru.Add(ctx, key, b, expiration)
+	_this.setBytes(ctx, key, b, expiration, tags...)
+
+	return nil
+}
+
+// keyLock returns the lock orders updates of key
+func (_this *Client) keyLock(key string) *sync.Mutex {
+	return &_this.keyLocks[core.HashKey(key)%keyLockStripes]
+}
+
+func (_this *Client) setBytes(ctx context.Context, key string, b []byte, expiration time.Duration, tags ...string) {
+	lock := _this.keyLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	remoteItem := _this.addLocal(ctx, key, b, expiration, tags...)
 
 	if _this.remoteCache {
-		_this.stream <- item{
-			key:        key,
-			value:      b,
-			expiration: expiration,
-			action:     AddElement,
-		}
+		_this.enqueue(remoteItem)
+	}
+}
+
+// onEvicted counts evictions and keeps the local tag index in sync with LRU,
+// it is called by LRU so it must not call back into LRU
+func (_this *Client) onEvicted(key interface{}, value interface{}, reason core.EvictReason) {
+	switch reason {
+	case core.EvictReasonCapacity:
+		atomic.AddUint32(&_this.stat.totalEvictions, 1)
+	case core.EvictReasonExpired:
+		atomic.AddUint32(&_this.stat.totalExpirations, 1)
+	}
+
+	_this.tagLock.Lock()
+	defer _this.tagLock.Unlock()
+
+	if k, ok := key.(string); ok {
+		_this.untag(k)
+	}
+}
+
+// addLocal adds the encoded value to LRU and returns the item to sync it to the remote cache
+func (_this *Client) addLocal(ctx context.Context, key string, b []byte, expiration time.Duration, tags ...string) item {
+	if expiration < 0 {
+		expiration = _this.expiration
 	}
+	expiration = _this.withJitter(expiration)
+
+	softExpiration := _this.getSoftExpiration(expiration)
+	_this.lru.AddWithSoftExpiration(ctx, key, b, softExpiration, expiration)
+	_this.tagLocal(key, tags)
 
 	atomic.AddInt64(&_this.stat.totalWriteBytes, int64(len(b)))
 
-	return nil
+	value := b
+	if _this.softExpiration > 0 {
+		value = wrapEnvelope(b, softExpiration, expiration)
+	}
+
+	return item{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		action:     AddElement,
+		tags:       tags,
+	}
 }
 
 func (_this *Client) get(ctx context.Context, key string) (Element, error) {
+	cached, err := _this.lookup(ctx, key)
+	if err == errNegativeHit {
+		return nil, Nil
+	}
+	return cached, err
+}
+
+// lookup gets the key from LRU then Redis, negative entries are reported with errNegativeHit
+func (_this *Client) lookup(ctx context.Context, key string) (Element, error) {
 	defer func() {
 		atomic.AddUint32(&_this.stat.totalReads, 1)
 		atomic.AddUint32(&_this.stat.totalOperations, 1)
 	}()
 
 	var (
-		rawValue    interface{}
 		encodeValue []byte
 		existed     bool
+		stale       bool
 		err         error
 	)
 
 	start := time.Now()
-	rawValue, existed = _this.lru.Get(ctx, key)
+	encodeValue, stale, existed = _this.lru.GetWithStale(ctx, key)
 
 	if duration := time.Since(start); duration > OptimalInMemAccessTime {
 		logger.Warn("get cache has reach optimal access time", zap.String("namespace", _this.namespace), zap.String("key", key), zap.String("duration", duration.String()))
@@ -241,102 +528,199 @@ func (_this *Client) get(ctx context.Context, key string) (Element, error) {
 
 	if !existed {
 		if _this.remoteCache {
-			rawValue, err = _this.redis.Get(ctx, _this.getRemoteKey(key)).Result()
+			encodeValue, err = _this.remote.Get(ctx, _this.getRemoteKey(key))
 			if duration := time.Since(start); duration > OptimalInMemAccessTime {
 				logger.Warn("get cache has reach optimal access time", zap.String("namespace", _this.namespace), zap.String("key", key), zap.String("duration", duration.String()))
 			}
 
 			if err != nil {
-				if err.Error() == redis.Nil.Error() {
-					atomic.AddUint32(&_this.stat.totalMisses, 1)
+				if err != Nil {
+					atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+					logger.Warn("get remote cache has error", zap.String("key", key), zap.String("error", err.Error()))
 				}
+				atomic.AddUint32(&_this.stat.totalMisses, 1)
 				return nil, Nil
 			}
 
-			atomic.AddUint32(&_this.stat.totalHits, 1)
-
-			encodeValue, err = _this.serializer.Encode(rawValue)
-			if err != nil {
-				return nil, fmt.Errorf("encode has error: %v", err)
+			encodeValue, stale = _this.unwrap(encodeValue)
+			if isNegative(encodeValue) {
+				atomic.AddUint32(&_this.stat.totalNegativeHits, 1)
+				return nil, errNegativeHit
 			}
+			atomic.AddUint32(&_this.stat.totalRemoteHits, 1)
 			goto Return
 		}
 
 		atomic.AddUint32(&_this.stat.totalMisses, 1)
 		return nil, Nil
-	} else {
-		encodeValue = rawValue.([]byte)
 	}
 
-	atomic.AddUint32(&_this.stat.totalHits, 1)
+	if isNegative(encodeValue) {
+		atomic.AddUint32(&_this.stat.totalNegativeHits, 1)
+		return nil, errNegativeHit
+	}
+
+	atomic.AddUint32(&_this.stat.totalLocalHits, 1)
 
 Return:
 	atomic.AddInt64(&_this.stat.totalReadBytes, int64(len(encodeValue)))
-	return &element{encodeValue}, nil
+	if stale {
+		_this.refresh(key)
+	}
+	return _this.newElement(encodeValue, stale), nil
+}
+
+// local gets the key from LRU without counting a read, it rechecks a key missed before waiting for the loader
+func (_this *Client) local(ctx context.Context, key string) (Element, error) {
+	b, stale, ok := _this.lru.GetWithStale(ctx, key)
+	if !ok {
+		return nil, Nil
+	}
+	if isNegative(b) {
+		return nil, errNegativeHit
+	}
+	return _this.newElement(b, stale), nil
+}
+
+func (_this *Client) getOrLoad(ctx context.Context, key string, expiration time.Duration, loader Loader) (Element, error) {
+	cached, err := _this.lookup(ctx, key)
+	if err == errNegativeHit {
+		return nil, Nil
+	}
+	if err != Nil {
+		return cached, err
+	}
+
+	// Only one loader is called per key, concurrent misses wait for its result. The load is detached from
+	// the caller starts it, so its cancellation does not fail the other callers
+	result := _this.loader.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(detach(ctx), _this.loadTimeout)
+		defer cancel()
+
+		cached, err := _this.local(loadCtx, key)
+		if err == errNegativeHit {
+			return nil, Nil
+		}
+		if err != Nil {
+			return cached, err
+		}
+
+		value, err := loader(loadCtx)
+		if err == Nil && _this.negativeExpiration > 0 {
+			_ = _this.setNotFound(loadCtx, key)
+			return nil, Nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := _this.serializer.Encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("encode value has error: %v", err)
+		}
+
+		atomic.AddUint32(&_this.stat.totalWrites, 1)
+		atomic.AddUint32(&_this.stat.totalOperations, 1)
+		_this.setBytes(loadCtx, key, b, expiration)
+
+		return _this.newElement(b, false), nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.(Element), nil
+	}
+}
+
+// detachedContext keeps the values of its parent without its deadline and cancellation
+type detachedContext struct {
+	context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
 }
 
 func (_this *Client) delete(ctx context.Context, key string) {
 	defer atomic.AddUint32(&_this.stat.totalOperations, 1)
 
+	_this.remove(ctx, key)
+}
+
+func (_this *Client) remove(ctx context.Context, key string) {
+	lock := _this.keyLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
 	_this.lru.Remove(ctx, key)
 
 	if _this.remoteCache {
-		_this.stream <- item{
+		_this.enqueue(item{
 			key:    key,
 			action: DeleteElement,
-		}
+		})
 	}
 }
 
+// contains reports whether the key holds a value, keys are cached as not found are not contained.
+// The remote cache is only asked for the value of a key when negative caching is enabled, instances sharing a namespace
+// are expected to share it
 func (_this *Client) contains(ctx context.Context, key string) bool {
 	defer atomic.AddUint32(&_this.stat.totalOperations, 1)
 
-	if ok := _this.lru.Contains(ctx, key); ok {
-		return ok
+	if b, ok := _this.lru.Peek(ctx, key); ok {
+		return !isNegative(b)
 	}
 
-	if _this.remoteCache {
-		ok, err := _this.redis.Exists(ctx, key).Result()
-		if err != nil && err.Error() != redis.Nil.Error() {
-			logger.Warn("get exist remote cache with redis has error", zap.String("key", key), zap.String("error", err.Error()))
+	if !_this.remoteCache {
+		return false
+	}
+
+	existed, err := _this.remote.Exists(ctx, _this.getRemoteKey(key))
+	if err == nil && existed && _this.negativeExpiration > 0 {
+		var b []byte
+		b, err = _this.remote.Get(ctx, _this.getRemoteKey(key))
+		if err == Nil {
 			return false
 		}
-		return ok == 1
+		b, _ = _this.unwrap(b)
+		existed = !isNegative(b)
+	}
+	if err != nil {
+		atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+		logger.Warn("get exist remote cache has error", zap.String("key", key), zap.String("error", err.Error()))
+		return false
 	}
 
-	return false
+	return existed
 }
 
 func (_this *Client) flush(ctx context.Context) error {
 	_this.lru.Purge(ctx)
 
 	if _this.remoteCache {
-		var (
-			cursor     uint64
-			remoteKeys []string
-			err        error
-		)
-
-		for {
-			var keys []string
-			keys, cursor, err = _this.redis.Scan(ctx, cursor, _this.namespace, 10).Result()
-			if err != nil {
-				return err
-			}
-
-			if cursor == 0 {
-				break
-			}
-
-			remoteKeys = append(remoteKeys, keys...)
+		if err := _this.flushRemote(ctx); err != nil {
+			return err
 		}
 
-		if len(remoteKeys) > 0 {
-			_, err = _this.redis.Del(ctx, remoteKeys...).Result()
-			if err != nil {
-				return err
-			}
-		}
+		_this.publishInvalidation(ctx, FlushElement)
 	}
 
 	_this.stat.reset()
@@ -344,34 +728,16 @@ func (_this *Client) flush(ctx context.Context) error {
 	return nil
 }
 
-func (_this *Client) autoSyncRemoteCache() {
-	go func(ctx context.Context) {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case item := <-_this.stream:
-				key := _this.getRemoteKey(item.key)
-				switch item.action {
-				case AddElement:
-					err := _this.redis.Set(_this.context, key, item.value, item.expiration).Err()
-					if err != nil && err.Error() != redis.Nil.Error() {
-						logger.Error("set remote cache with redis has error", zap.String("key", key), zap.String("error", err.Error()))
-					}
-				case DeleteElement:
-					_, err := _this.redis.Del(_this.context, key).Result()
-					if err != nil && err.Error() != redis.Nil.Error() {
-						logger.Error("delete remote cache with redis has error", zap.String("key", key), zap.String("error", err.Error()))
-					}
-				}
+func (_this *Client) snapshot(ctx context.Context, stats StatsSnapshot) StatsSnapshot {
+	stats.LocalItems = _this.lru.Len(ctx)
+	stats.LocalBytes = _this.lru.Cost(ctx)
+	stats.MaxLocalBytes = _this.maxBytes
 
-			}
-		}
-	}(_this.context)
+	return stats
 }
 
-func (_this *Client) report() string {
-	duration := time.Since(time.Unix(_this.stat.timeStart, 0)).Seconds()
+func (_this *Client) report(ctx context.Context) string {
+	stats := _this.snapshot(ctx, _this.stat.windowSnapshot(_this.namespace))
 
 	return fmt.Sprintf(`
 		#Cache Stat
@@ -384,80 +750,141 @@ func (_this *Client) report() string {
 		Read ops per second: %.2f,
 		Write ops per second: %.2f,
 		Total hits: %v,
-		Total misses: %v`,
-		_this.stat.totalOperations,
-		_this.stat.totalWrites,
-		_this.stat.totalReads,
-		_this.stat.totalOperations-_this.stat.totalWrites-_this.stat.totalReads,
-		_this.stat.totalWriteBytes,
-		_this.stat.totalReadBytes,
-		float64(_this.stat.totalWrites*1.0)/duration,
-		float64(_this.stat.totalReads*1.0)/duration,
-		_this.stat.totalHits,
-		_this.stat.totalMisses)
+		Total local hits: %v,
+		Total remote hits: %v,
+		Total misses: %v,
+		Total negative hits: %v,
+		Hit ratio: %.2f,
+		Total evictions: %v,
+		Total expirations: %v,
+		Total remote errors: %v,
+		Total dropped writes: %v,
+		Sync queue depth: %v,
+		Local items: %v,
+		Local bytes: %v,
+		Max local bytes: %v`,
+		stats.Operations,
+		stats.Writes,
+		stats.Reads,
+		stats.Operations-stats.Writes-stats.Reads,
+		stats.WriteBytes,
+		stats.ReadBytes,
+		stats.ReadsPerSecond,
+		stats.WritesPerSecond,
+		stats.Hits,
+		stats.LocalHits,
+		stats.RemoteHits,
+		stats.Misses,
+		stats.NegativeHits,
+		stats.HitRatio,
+		stats.Evictions,
+		stats.Expirations,
+		stats.RemoteErrors,
+		stats.DroppedWrites,
+		stats.SyncQueueDepth,
+		stats.LocalItems,
+		stats.LocalBytes,
+		stats.MaxLocalBytes)
 }
 
 func (_this *Client) Set(ctx context.Context, key string, value interface{}, expiration int) (err error) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
 	if expiration < 0 {
 		expiration = -1
 	}
 
-	_this.hook.Process(ctx, func() {
-		err = _this.set(ctx, key, value, time.Second*time.Duration(expiration))
-	}, "set")
+	err = _this.process(ctx, "set", key, func(ctx context.Context) error {
+		return _this.set(ctx, key, value, time.Second*time.Duration(expiration))
+	})
 
 	return
 }
 
 func (_this *Client) Get(ctx context.Context, key string) (element Element, err error) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	err = _this.process(ctx, "get", key, func(ctx context.Context) (err error) {
 		element, err = _this.get(ctx, key)
-	}, "get")
+		return
+	})
 
 	return
 }
 
-func (_this *Client) Delete(ctx context.Context, key string) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
+// GetInto gets the key and decodes its value into out with the configured serializer
+func (_this *Client) GetInto(ctx context.Context, key string, out interface{}) (err error) {
+	return _this.process(ctx, "get_into", key, func(ctx context.Context) error {
+		element, err := _this.get(ctx, key)
+		if err != nil {
+			return err
+		}
+		return element.Decode(out)
+	})
+}
+
+// GetOrLoad gets the key from LRU then Redis, if the key is missed in every layer, the loader is called once
+// even under concurrent misses and its result is written back through both layers.
+// When negative caching is enabled and the loader returns Nil, the key is cached as not found
+func (_this *Client) GetOrLoad(ctx context.Context, key string, expiration int, loader Loader) (element Element, err error) {
+	if loader == nil {
+		return nil, fmt.Errorf("loader must not be empty")
+	}
+
+	if expiration < 0 {
+		expiration = -1
+	}
+
+	err = _this.process(ctx, "get_or_load", key, func(ctx context.Context) (err error) {
+		element, err = _this.getOrLoad(ctx, key, time.Second*time.Duration(expiration), loader)
+		return
+	})
+
+	return
+}
 
-	_this.hook.Process(ctx, func() {
+func (_this *Client) Delete(ctx context.Context, key string) {
+	_ = _this.process(ctx, "delete", key, func(ctx context.Context) error {
 		_this.delete(ctx, key)
-	}, "delete")
+		return nil
+	})
 }
 
 func (_this *Client) Contains(ctx context.Context, key string) (existed bool) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
+	_ = _this.process(ctx, "contains", key, func(ctx context.Context) error {
 		existed = _this.contains(ctx, key)
-	}, "contains")
+		return nil
+	})
 
 	return
 }
 
+// Flush removes every key of the namespace from both layers and starts a new window of the statistics of Report
 func (_this *Client) Flush(ctx context.Context) (err error) {
-	_this.lock.Lock()
-	defer _this.lock.Unlock()
-
-	_this.hook.Process(ctx, func() {
-		err = _this.flush(ctx)
-	}, "flush")
+	err = _this.process(ctx, "flush", nil, func(ctx context.Context) error {
+		return _this.flush(ctx)
+	})
 
 	return
 }
 
 func (_this *Client) Report(ctx context.Context) (result string) {
-	_this.hook.Process(ctx, func() {
-		result = _this.report()
-	}, "report")
+	_ = _this.process(ctx, "report", nil, func(ctx context.Context) error {
+		result = _this.report(ctx)
+		return nil
+	})
 
 	return
 }
+
+// Close stops syncing new writes to the remote cache and waits until pending writes are flushed or ctx is done,
+// LRU is dumped to the snapshot file if it is set
+func (_this *Client) Close(ctx context.Context) (err error) {
+	err = _this.process(ctx, "close", nil, func(ctx context.Context) error {
+		return _this.close(ctx)
+	})
+
+	return
+}
+
+// Stats returns a snapshot of the statistics of the cache since it is created, counters are never reset so they can be
+// exported as prometheus counters. Flush only resets the statistics of Report
+func (_this *Client) Stats() StatsSnapshot {
+	return _this.snapshot(_this.context, _this.stat.snapshot(_this.namespace))
+}
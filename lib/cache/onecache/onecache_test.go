@@ -2,14 +2,19 @@ package onecache
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 
-	"github.com/1infras/go-kit/driver/redis"
+	"github.com/1infras/go-kit/lib/cache/lru/core"
+	"github.com/1infras/go-kit/lib/hook/common"
 	"github.com/1infras/go-kit/logger"
 )
 
@@ -30,15 +35,14 @@ func TestOneCache(t *testing.T) {
 	logger.InitLogger(logger.InfoLevel)
 	ctx := context.Background()
 
-	redisCache, err := redis.NewDefaultRedisUniversalClient()
-	assert.Nil(t, err)
+	store := NewFakeStore()
 
 	oneCache, err := NewOneCache(
 		SetContext(ctx),
 		SetExpiration(3*time.Second),
 		SetMaxItems(10),
 		SetRemoteCacheNamespace("redis_cache"),
-		SetRemoteCache(redisCache))
+		SetRemoteStore(store))
 	assert.Nil(t, err)
 
 	oneCache.AddHook(&testHook{})
@@ -66,4 +70,711 @@ func TestOneCache(t *testing.T) {
 
 	err = oneCache.Flush(ctx)
 	assert.Nil(t, err)
-}
\ No newline at end of file
+}
+func TestOneCacheInvalidation(t *testing.T) {
+	logger.InitLogger(logger.InfoLevel)
+	ctx := context.Background()
+
+	store := NewFakeStore()
+
+	newCache := func() OneCache {
+		c, err := NewOneCache(
+			SetContext(ctx),
+			SetExpiration(10*time.Second),
+			SetRemoteCacheNamespace("redis_cache_invalidation"),
+			SetRemoteStore(store),
+			SetInvalidation(true))
+		assert.Nil(t, err)
+		return c
+	}
+
+	first, second := newCache(), newCache()
+
+	err := second.Set(ctx, "price", 1, 10)
+	assert.Nil(t, err)
+
+	// Wait for the write of second is synced before first overrides it
+	assert.Eventually(t, func() bool {
+		v, err := store.Get(ctx, "redis_cache_invalidation:price")
+		return err == nil && string(v) == "1"
+	}, 3*time.Second, 10*time.Millisecond)
+
+	err = first.Set(ctx, "price", 2, 10)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		v, err := second.Get(ctx, "price")
+		if err != nil {
+			return false
+		}
+		price, err := v.Int()
+		return err == nil && price == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	first.Delete(ctx, "price")
+
+	assert.Eventually(t, func() bool {
+		_, err := second.Get(ctx, "price")
+		return err == Nil
+	}, 3*time.Second, 10*time.Millisecond)
+
+	_, err = NewOneCache(SetInvalidation(true))
+	assert.NotNil(t, err)
+
+	err = first.Flush(ctx)
+	assert.Nil(t, err)
+}
+
+func TestOneCacheGetOrLoad(t *testing.T) {
+	logger.InitLogger(logger.InfoLevel)
+	ctx := context.Background()
+
+	store := NewFakeStore()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(3*time.Second),
+		SetRemoteCacheNamespace("redis_cache_loader"),
+		SetRemoteStore(store))
+	assert.Nil(t, err)
+
+	oneCache.AddHook(&testHook{})
+
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return 42, nil
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := oneCache.GetOrLoad(ctx, "answer", 3, loader)
+			assert.Nil(t, err)
+			vk, err := v.Int()
+			assert.Nil(t, err)
+			assert.Equal(t, 42, vk)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	v, err := oneCache.Get(ctx, "answer")
+	assert.Nil(t, err)
+	assert.Equal(t, "42", v.String())
+
+	_, err = oneCache.GetOrLoad(ctx, "missing", 3, func(ctx context.Context) (interface{}, error) {
+		return nil, fmt.Errorf("origin is unavailable")
+	})
+	assert.NotNil(t, err)
+
+	err = oneCache.Flush(ctx)
+	assert.Nil(t, err)
+}
+
+func TestOneCacheGetOrLoadCancel(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetMaxItems(10))
+	assert.Nil(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		return 42, ctx.Err()
+	}
+
+	// The caller starts the load cancels, the other caller still gets the value
+	first, cancel := context.WithCancel(ctx)
+	errs := make(chan error, 1)
+	go func() {
+		_, err := oneCache.GetOrLoad(first, "answer", -1, loader)
+		errs <- err
+	}()
+	<-started
+
+	values := make(chan Element, 1)
+	go func() {
+		v, err := oneCache.GetOrLoad(ctx, "answer", -1, loader)
+		assert.Nil(t, err)
+		values <- v
+	}()
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+	close(release)
+
+	v := <-values
+	vk, err := v.Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 42, vk)
+
+	// Every caller counts one read, either a miss or a hit of the loaded value
+	stats := oneCache.Stats()
+	assert.Equal(t, uint64(2), stats.Reads)
+	assert.Equal(t, uint64(2), stats.Misses+stats.LocalHits)
+}
+
+func TestOneCacheDecode(t *testing.T) {
+	logger.InitLogger(logger.InfoLevel)
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(3*time.Second),
+		SetMaxItems(10))
+	assert.Nil(t, err)
+
+	type product struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Price float64  `json:"price"`
+		Tags  []string `json:"tags"`
+	}
+
+	expected := product{ID: 1, Name: "book", Price: 9.5, Tags: []string{"paper"}}
+	err = oneCache.Set(ctx, "product", expected, 3)
+	assert.Nil(t, err)
+
+	var actual product
+	err = oneCache.GetInto(ctx, "product", &actual)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	v, err := oneCache.Get(ctx, "product")
+	assert.Nil(t, err)
+
+	actual = product{}
+	err = v.Decode(&actual)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	err = oneCache.Set(ctx, "count", "10", 3)
+	assert.Nil(t, err)
+
+	v, err = oneCache.Get(ctx, "count")
+	assert.Nil(t, err)
+
+	count, err := v.Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 10, count)
+	assert.Equal(t, "10", v.String())
+
+	err = oneCache.GetInto(ctx, "unknown", &actual)
+	assert.Equal(t, Nil, err)
+}
+
+func TestOneCacheBatch(t *testing.T) {
+	logger.InitLogger(logger.InfoLevel)
+	ctx := context.Background()
+
+	store := NewFakeStore()
+
+	newCache := func() OneCache {
+		c, err := NewOneCache(
+			SetContext(ctx),
+			SetExpiration(3*time.Second),
+			SetRemoteCacheNamespace("redis_cache_batch"),
+			SetRemoteStore(store))
+		assert.Nil(t, err)
+		return c
+	}
+
+	writer, reader := newCache(), newCache()
+
+	err := writer.MSet(ctx, map[string]interface{}{
+		"a": 1,
+		"b": 2,
+		"c": 3,
+	}, 3)
+	assert.Nil(t, err)
+
+	elements, err := writer.MGet(ctx, "a", "b", "c", "d")
+	assert.Nil(t, err)
+	assert.Len(t, elements, 3)
+
+	// reader has an empty LRU so every key is resolved from the remote store
+	assert.Eventually(t, func() bool {
+		elements, err := reader.MGet(ctx, "a", "b", "c", "d")
+		return err == nil && len(elements) == 3
+	}, 3*time.Second, 10*time.Millisecond)
+
+	elements, err = reader.MGet(ctx, "a", "b", "c", "d")
+	assert.Nil(t, err)
+	for key, expected := range map[string]int{"a": 1, "b": 2, "c": 3} {
+		v, err := elements[key].Int()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, v)
+	}
+	_, existed := elements["d"]
+	assert.False(t, existed)
+
+	writer.MDelete(ctx, "a", "b", "c")
+
+	assert.Eventually(t, func() bool {
+		elements, err := reader.MGet(ctx, "a", "b", "c")
+		return err == nil && len(elements) == 0
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestOneCacheStaleWhileRevalidate(t *testing.T) {
+	logger.InitLogger(logger.InfoLevel)
+	ctx := context.Background()
+
+	store := NewFakeStore()
+
+	var calls int32
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(5*time.Second),
+		SetSoftExpiration(200*time.Millisecond),
+		SetRefresher(func(ctx context.Context, key string) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(50 * time.Millisecond)
+			return 2, nil
+		}),
+		SetRemoteCacheNamespace("redis_cache_stale"),
+		SetRemoteStore(store))
+	assert.Nil(t, err)
+
+	err = oneCache.Set(ctx, "price", 1, 5)
+	assert.Nil(t, err)
+
+	v, err := oneCache.Get(ctx, "price")
+	assert.Nil(t, err)
+	assert.False(t, v.Stale())
+
+	time.Sleep(300 * time.Millisecond)
+
+	// Stale values are still served while a single refresh runs in background
+	for i := 0; i < 10; i++ {
+		v, err = oneCache.Get(ctx, "price")
+		assert.Nil(t, err)
+		price, err := v.Int()
+		assert.Nil(t, err)
+		if v.Stale() {
+			assert.Equal(t, 1, price)
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		v, err := oneCache.Get(ctx, "price")
+		if err != nil || v.Stale() {
+			return false
+		}
+		price, err := v.Int()
+		return err == nil && price == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// The remote value carries its soft expiration too
+	remote, err := NewOneCache(
+		SetContext(ctx),
+		SetSoftExpiration(time.Minute),
+		SetRemoteCacheNamespace("redis_cache_stale"),
+		SetRemoteStore(store))
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		v, err := remote.Get(ctx, "price")
+		return err == nil && v.Stale()
+	}, 3*time.Second, 10*time.Millisecond)
+
+	v, err = remote.Get(ctx, "price")
+	assert.Nil(t, err)
+	price, err := v.Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, price)
+}
+
+func TestOneCacheKeysAndFlush(t *testing.T) {
+	logger.InitLogger(logger.InfoLevel)
+	ctx := context.Background()
+
+	store := NewFakeStore()
+
+	err := store.Set(ctx, "foreign_key", []byte("1"), 10*time.Second)
+	assert.Nil(t, err)
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(10*time.Second),
+		SetRemoteCacheNamespace("redis_cache_flush"),
+		SetRemoteStore(store))
+	assert.Nil(t, err)
+
+	values := make(map[string]interface{})
+	for i := 0; i < 25; i++ {
+		values[fmt.Sprintf("key_%d", i)] = i
+	}
+	err = oneCache.MSet(ctx, values, 10)
+	assert.Nil(t, err)
+
+	count := func(pattern string) int {
+		n := 0
+		iterator := oneCache.Keys(ctx, pattern)
+		for iterator.Next(ctx) {
+			_, existed := values[iterator.Key()]
+			assert.True(t, existed, iterator.Key())
+			n++
+		}
+		assert.Nil(t, iterator.Err())
+		return n
+	}
+
+	assert.Eventually(t, func() bool {
+		return count("") == 25
+	}, 3*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 11, count("key_1*"))
+
+	// Contains looks up the namespaced key in the remote store
+	remote, err := NewOneCache(
+		SetContext(ctx),
+		SetRemoteCacheNamespace("redis_cache_flush"),
+		SetRemoteStore(store))
+	assert.Nil(t, err)
+	assert.True(t, remote.Contains(ctx, "key_1"))
+	assert.False(t, remote.Contains(ctx, "foreign_key"))
+
+	err = oneCache.Flush(ctx)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 0, count(""))
+	assert.False(t, remote.Contains(ctx, "key_1"))
+
+	existed, err := store.Exists(ctx, "foreign_key")
+	assert.Nil(t, err)
+	assert.True(t, existed)
+}
+
+func TestOneCacheFlushPrefixNamespace(t *testing.T) {
+	ctx := context.Background()
+	store := NewFakeStore()
+
+	users, err := NewOneCache(SetContext(ctx), SetRemoteCacheNamespace("users"), SetRemoteStore(store))
+	assert.Nil(t, err)
+	archive, err := NewOneCache(SetContext(ctx), SetRemoteCacheNamespace("users_archive"), SetRemoteStore(store))
+	assert.Nil(t, err)
+
+	assert.Nil(t, users.Set(ctx, "a", 1, 10))
+	assert.Nil(t, archive.Set(ctx, "b", 2, 10))
+	assert.Nil(t, users.Close(ctx))
+	assert.Nil(t, archive.Close(ctx))
+
+	var keys []string
+	iterator := users.Keys(ctx, "")
+	for iterator.Next(ctx) {
+		keys = append(keys, iterator.Key())
+	}
+	assert.Nil(t, iterator.Err())
+	assert.Equal(t, []string{"a"}, keys)
+
+	// Flush of a namespace keeps keys of namespaces it is a prefix of
+	err = users.Flush(ctx)
+	assert.Nil(t, err)
+
+	_, err = store.Get(ctx, "users:a")
+	assert.Equal(t, Nil, err)
+	b, err := store.Get(ctx, "users_archive:b")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", string(b))
+
+	_, err = NewOneCache(SetRemoteCacheNamespace("users:v2"))
+	assert.NotNil(t, err)
+}
+
+func TestOneCacheMaxBytes(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetMaxBytes(1000))
+	assert.Nil(t, err)
+
+	large := strings.Repeat("a", 400)
+	for _, key := range []string{"a", "b", "c"} {
+		err = oneCache.Set(ctx, key, large, -1)
+		assert.Nil(t, err)
+	}
+
+	// Every value is 402 bytes once encoded so only 2 of them fit
+	_, err = oneCache.Get(ctx, "a")
+	assert.Equal(t, Nil, err)
+
+	stats := oneCache.Stats()
+	assert.Equal(t, 2, stats.LocalItems)
+	assert.Equal(t, int64(804), stats.LocalBytes)
+	assert.Equal(t, int64(1000), stats.MaxLocalBytes)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Contains(t, oneCache.Report(ctx), "Local bytes: 804")
+
+	_, err = NewOneCache(SetMaxBytes(0))
+	assert.NotNil(t, err)
+}
+
+func TestOneCacheCleanupInterval(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(20*time.Millisecond),
+		SetCleanupInterval(10*time.Millisecond))
+	assert.Nil(t, err)
+
+	err = oneCache.SetWithTags(ctx, "a", 1, -1, "tag")
+	assert.Nil(t, err)
+	err = oneCache.Set(ctx, "b", 2, 10)
+	assert.Nil(t, err)
+
+	// The janitor removes the expired item without reading it
+	assert.Eventually(t, func() bool {
+		stats := oneCache.Stats()
+		return stats.Expirations == 1 && stats.LocalItems == 1
+	}, time.Second, 10*time.Millisecond)
+
+	client := oneCache.(*Client)
+	client.tagLock.Lock()
+	assert.Empty(t, client.keyTags)
+	client.tagLock.Unlock()
+
+	assert.Equal(t, uint64(0), oneCache.Stats().Evictions)
+	assert.Nil(t, oneCache.Close(ctx))
+}
+
+func TestOneCacheEvictionPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	for _, policy := range []core.Policy{core.Policy2Q, core.PolicyARC, core.PolicyTinyLFU} {
+		oneCache, err := NewOneCache(
+			SetContext(ctx),
+			SetMaxItems(100),
+			SetEvictionPolicy(policy))
+		assert.Nil(t, err)
+
+		// The hot key survives a scan over many keys are read once
+		err = oneCache.Set(ctx, "hot", 1, -1)
+		assert.Nil(t, err)
+		for i := 0; i < 3; i++ {
+			_, err = oneCache.Get(ctx, "hot")
+			assert.Nil(t, err)
+		}
+
+		for i := 0; i < 1000; i++ {
+			err = oneCache.Set(ctx, fmt.Sprintf("scan_%d", i), i, -1)
+			assert.Nil(t, err)
+		}
+
+		_, err = oneCache.Get(ctx, "hot")
+		assert.Nil(t, err, policy.String())
+		assert.Equal(t, 100, oneCache.Stats().LocalItems)
+	}
+
+	_, err := NewOneCache(SetEvictionPolicy(core.PolicyARC), SetMaxBytes(1000))
+	assert.NotNil(t, err)
+}
+
+func TestOneCacheShards(t *testing.T) {
+	ctx := context.Background()
+	store := NewFakeStore()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetMaxItems(1000),
+		SetShards(8),
+		SetRemoteCacheNamespace("shards"),
+		SetRemoteStore(store))
+	assert.Nil(t, err)
+
+	// Writers race on the same keys, LRU and the remote cache must end up with the same value of every key
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("key_%d", i%20)
+				switch i % 5 {
+				case 0:
+					oneCache.Delete(ctx, key)
+				case 1:
+					assert.Nil(t, oneCache.MSet(ctx, map[string]interface{}{key: g}, -1))
+				default:
+					assert.Nil(t, oneCache.Set(ctx, key, g*1000+i, -1))
+				}
+				_, _ = oneCache.Get(ctx, key)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.Nil(t, oneCache.Close(ctx))
+
+	client := oneCache.(*Client)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key_%d", i)
+		local, inLocal := client.lru.Peek(ctx, key)
+		remote, err := store.Get(ctx, client.getRemoteKey(key))
+		assert.Equal(t, inLocal, err == nil, key)
+		if inLocal {
+			assert.Equal(t, local, remote, key)
+		}
+	}
+
+	_, err = NewOneCache(SetShards(0))
+	assert.NotNil(t, err)
+}
+
+type operationHook struct {
+	operations []common.Operation
+}
+
+func (_this *operationHook) BeforeOperation(ctx context.Context, op *common.Operation) context.Context {
+	return ctx
+}
+
+func (_this *operationHook) AfterOperation(ctx context.Context, op *common.Operation) {
+	_this.operations = append(_this.operations, *op)
+}
+
+func TestOneCacheOperationHook(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetRemoteCacheNamespace("operations"))
+	assert.Nil(t, err)
+
+	hook := &operationHook{}
+	oneCache.AddOperationHook(hook)
+
+	assert.Nil(t, oneCache.Set(ctx, "a", 1, -1))
+	_, err = oneCache.Get(ctx, "missing")
+	assert.Equal(t, Nil, err)
+
+	failure := fmt.Errorf("failure")
+	_, err = oneCache.GetOrLoad(ctx, "b", -1, func(ctx context.Context) (interface{}, error) {
+		return nil, failure
+	})
+	assert.Equal(t, failure, err)
+	oneCache.MDelete(ctx, "a", "b")
+
+	assert.Len(t, hook.operations, 4)
+	for _, op := range hook.operations {
+		assert.Equal(t, "operations", op.Attribute(common.AttributeNamespace))
+		assert.False(t, op.Start.IsZero())
+	}
+
+	assert.Equal(t, "set", hook.operations[0].Name)
+	assert.Equal(t, "a", hook.operations[0].Key)
+	assert.Nil(t, hook.operations[0].Err)
+
+	// A missed key is not an error of the operation
+	assert.Equal(t, "get", hook.operations[1].Name)
+	assert.Equal(t, "missing", hook.operations[1].Key)
+	assert.Nil(t, hook.operations[1].Err)
+
+	assert.Equal(t, "get_or_load", hook.operations[2].Name)
+	assert.Equal(t, failure, hook.operations[2].Err)
+
+	assert.Equal(t, "mdelete", hook.operations[3].Name)
+	assert.Nil(t, hook.operations[3].Key)
+}
+
+func TestOneCacheInterceptor(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetRemoteCacheNamespace("interceptor"))
+	assert.Nil(t, err)
+
+	limited := fmt.Errorf("rate limited")
+	oneCache.AddInterceptor(func(ctx context.Context, op *common.Operation, next common.Next) error {
+		if op.Name == "set" && op.Key == "limited" {
+			return limited
+		}
+		return next(ctx)
+	})
+
+	assert.Equal(t, limited, oneCache.Set(ctx, "limited", 1, -1))
+	assert.False(t, oneCache.Contains(ctx, "limited"))
+
+	// Nil passes through interceptors as a miss
+	_, err = oneCache.Get(ctx, "limited")
+	assert.Equal(t, Nil, err)
+
+	assert.Nil(t, oneCache.Set(ctx, "a", 1, -1))
+	assert.True(t, oneCache.Contains(ctx, "a"))
+}
+
+func TestOneCacheInterceptorContext(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetRemoteCacheNamespace("interceptor_context"))
+	assert.Nil(t, err)
+
+	type tenantKey struct{}
+	oneCache.AddInterceptor(func(ctx context.Context, op *common.Operation, next common.Next) error {
+		return next(context.WithValue(ctx, tenantKey{}, "tenant"))
+	})
+
+	// The loader sees the context passed by interceptors
+	element, err := oneCache.GetOrLoad(ctx, "a", -1, func(ctx context.Context) (interface{}, error) {
+		return ctx.Value(tenantKey{}), nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant", element.String())
+}
+
+func TestOneCacheInterceptorShortCircuit(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetRemoteCacheNamespace("interceptor_short_circuit"))
+	assert.Nil(t, err)
+	assert.Nil(t, oneCache.Set(ctx, "a", 1, -1))
+
+	oneCache.AddInterceptor(func(ctx context.Context, op *common.Operation, next common.Next) error {
+		if op.Name == "get" {
+			return nil
+		}
+		return next(ctx)
+	})
+
+	// Operations skipped without an error have no result
+	element, err := oneCache.Get(ctx, "a")
+	assert.Equal(t, ErrShortCircuited, err)
+	assert.Nil(t, element)
+
+	_, err = NewTyped[int](oneCache).Get(ctx, "a")
+	assert.Equal(t, ErrShortCircuited, err)
+
+	assert.True(t, oneCache.Contains(ctx, "a"))
+}
+
+func TestOneCacheEnvelopeWithoutSoftExpiration(t *testing.T) {
+	ctx := context.Background()
+	store := NewFakeStore()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetRemoteCacheNamespace("envelope_payload"),
+		SetRemoteStore(store))
+	assert.Nil(t, err)
+
+	// A payload looks like an envelope is kept intact when soft expiration is off
+	payload := wrapEnvelope([]byte("value"), time.Nanosecond, 0)
+	assert.Nil(t, store.Set(ctx, "envelope_payload:a", payload, 0))
+
+	element, err := oneCache.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, payload, element.Bytes())
+	assert.False(t, element.Stale())
+
+	elements, err := oneCache.MGet(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, payload, elements["a"].Bytes())
+}
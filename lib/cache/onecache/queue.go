@@ -0,0 +1,314 @@
+package onecache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"github.com/1infras/go-kit/logger"
+)
+
+// OverflowPolicy decides what happens to a remote write when the sync queue is full
+type OverflowPolicy int
+
+const (
+	// BlockOnOverflow waits until the queue has room
+	BlockOnOverflow OverflowPolicy = iota
+	// DropOldestOnOverflow drops the oldest pending write to make room, deletes and tag invalidations are not dropped
+	// but written through in the caller so the remote cache is never left with stale values
+	DropOldestOnOverflow
+	// WriteThroughOnOverflow writes to the remote cache in the caller,
+	// pending writes of the same key are older so they are discarded
+	WriteThroughOnOverflow
+)
+
+const (
+	DefaultSyncQueueSize = 10000
+	DefaultSyncBatchSize = 100
+)
+
+// SetSyncQueueSize sets the number of remote writes can be pending before the overflow policy applies
+func SetSyncQueueSize(size int) ClientOptionFunc {
+	return func(c *Client) error {
+		if size <= 0 {
+			return fmt.Errorf("sync queue size must be greater than 0")
+		}
+		c.syncQueueSize = size
+		return nil
+	}
+}
+
+// SetSyncBatchSize sets the max number of remote writes are flushed with a single pipeline
+func SetSyncBatchSize(size int) ClientOptionFunc {
+	return func(c *Client) error {
+		if size <= 0 {
+			return fmt.Errorf("sync batch size must be greater than 0")
+		}
+		c.syncBatchSize = size
+		return nil
+	}
+}
+
+// SetOverflowPolicy sets the policy is applied when the sync queue is full
+func SetOverflowPolicy(policy OverflowPolicy) ClientOptionFunc {
+	return func(c *Client) error {
+		switch policy {
+		case BlockOnOverflow, DropOldestOnOverflow, WriteThroughOnOverflow:
+			c.overflowPolicy = policy
+			return nil
+		default:
+			return fmt.Errorf("overflow policy %d is not supported", policy)
+		}
+	}
+}
+
+// syncState orders remote writes of the sync queue with writes through the queue on overflow
+type syncState struct {
+	lock sync.Mutex
+	// sequence numbers items in the order they are enqueued, items of a key are enqueued under its key lock
+	sequence uint64
+	// writtenThrough is the sequence of the last write through of a key, pending items of the key enqueued
+	// before it are discarded
+	writtenThrough map[string]uint64
+}
+
+// enqueue pushes items to be synced to the remote cache, items are dropped once the cache is closed
+func (_this *Client) enqueue(items ...item) {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	for _, it := range items {
+		it.sequence = atomic.AddUint64(&_this.syncing.sequence, 1)
+
+		if atomic.LoadInt32(&_this.closed) == 1 {
+			logger.Warn("onecache has closed, remote write is dropped", zap.String("namespace", _this.namespace), zap.String("key", it.key))
+			_this.dropWrite(it)
+			continue
+		}
+
+		atomic.AddInt64(&_this.stat.pendingWrites, 1)
+
+		switch _this.overflowPolicy {
+		case DropOldestOnOverflow:
+			for !_this.offer(it) {
+				select {
+				case oldest := <-_this.stream:
+					if oldest.action == AddElement {
+						atomic.AddInt64(&_this.stat.pendingWrites, -1)
+						_this.dropWrite(oldest)
+					} else {
+						_this.writeThrough(_this.context, oldest)
+					}
+				default:
+				}
+			}
+		case WriteThroughOnOverflow:
+			if !_this.offer(it) {
+				_this.writeThrough(_this.context, it)
+			}
+		default:
+			// The sync worker stops once the context of the cache is done, the write would wait forever
+			select {
+			case _this.stream <- it:
+			case <-_this.context.Done():
+				atomic.AddInt64(&_this.stat.pendingWrites, -1)
+				_this.dropWrite(it)
+			case <-_this.closing:
+				atomic.AddInt64(&_this.stat.pendingWrites, -1)
+				_this.dropWrite(it)
+			}
+		}
+	}
+}
+
+// dropWrite counts a remote write is dropped and emits its EventRemoteSyncFailure
+func (_this *Client) dropWrite(it item) {
+	atomic.AddUint32(&_this.stat.totalDroppedWrites, 1)
+	_this.syncFailed(it.key, ErrWriteDropped)
+}
+
+func (_this *Client) offer(it item) bool {
+	select {
+	case _this.stream <- it:
+		return true
+	default:
+		return false
+	}
+}
+
+// nextBatch takes up to syncBatchSize pending items without waiting
+func (_this *Client) nextBatch(batch []item) []item {
+	for len(batch) < _this.syncBatchSize {
+		select {
+		case it := <-_this.stream:
+			batch = append(batch, it)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (_this *Client) autoSyncRemoteCache() {
+	go func(ctx context.Context) {
+		defer close(_this.drained)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-_this.closing:
+				for batch := _this.nextBatch(nil); len(batch) > 0; batch = _this.nextBatch(nil) {
+					_this.syncRemoteCache(ctx, batch)
+				}
+				return
+			case it := <-_this.stream:
+				_this.syncRemoteCache(ctx, _this.nextBatch([]item{it}))
+			}
+		}
+	}(_this.context)
+}
+
+// writeThrough writes an item did not fit in the sync queue, the pending items of its key are older so they
+// are discarded when they are taken from the queue
+func (_this *Client) writeThrough(ctx context.Context, it item) {
+	_this.syncing.lock.Lock()
+	defer _this.syncing.lock.Unlock()
+
+	_this.syncItems(ctx, []item{it})
+
+	if it.action == AddElement || it.action == DeleteElement {
+		if _this.syncing.writtenThrough == nil {
+			_this.syncing.writtenThrough = make(map[string]uint64)
+		}
+		_this.syncing.writtenThrough[it.key] = it.sequence
+	}
+}
+
+// syncRemoteCache writes a batch of items taken from the sync queue to the remote store
+func (_this *Client) syncRemoteCache(ctx context.Context, items []item) {
+	_this.syncing.lock.Lock()
+	defer _this.syncing.lock.Unlock()
+
+	_this.syncItems(ctx, _this.discardOverwritten(items))
+}
+
+// discardOverwritten removes items were overwritten by a write through, it is called with the sync lock held.
+// The queue is FIFO, so once an item enqueued after a write through is taken, no item before it is pending
+func (_this *Client) discardOverwritten(items []item) []item {
+	if len(_this.syncing.writtenThrough) == 0 {
+		return items
+	}
+
+	var last uint64
+	kept := make([]item, 0, len(items))
+	for _, it := range items {
+		if it.sequence > last {
+			last = it.sequence
+		}
+		if it.action == AddElement || it.action == DeleteElement {
+			if sequence, ok := _this.syncing.writtenThrough[it.key]; ok && it.sequence < sequence {
+				atomic.AddInt64(&_this.stat.pendingWrites, -1)
+				continue
+			}
+		}
+		kept = append(kept, it)
+	}
+
+	for key, sequence := range _this.syncing.writtenThrough {
+		if sequence < last {
+			delete(_this.syncing.writtenThrough, key)
+		}
+	}
+
+	return kept
+}
+
+// syncItems writes items to the remote store, tags are invalidated in order with writes around them
+func (_this *Client) syncItems(ctx context.Context, items []item) {
+	defer atomic.AddInt64(&_this.stat.pendingWrites, -int64(len(items)))
+
+	start := 0
+	for i, it := range items {
+		if it.action == InvalidateTagElement {
+			_this.writeRemoteItems(ctx, items[start:i])
+			_this.invalidateRemoteTag(ctx, it.key)
+			start = i + 1
+		}
+	}
+	_this.writeRemoteItems(ctx, items[start:])
+}
+
+// writeRemoteItems writes items within a round trip if the remote store supports batches
+func (_this *Client) writeRemoteItems(ctx context.Context, items []item) {
+	if len(items) == 0 {
+		return
+	}
+
+	writes := make([]RemoteWrite, len(items))
+	for i, item := range items {
+		writes[i] = RemoteWrite{
+			Key:        _this.getRemoteKey(item.key),
+			Value:      item.value,
+			Expiration: item.expiration,
+			Delete:     item.action == DeleteElement,
+		}
+	}
+
+	errs := writeRemote(ctx, _this.remote, writes)
+
+	synced := make(map[int][]string)
+	var tagged []item
+	for i, err := range errs {
+		if err != nil {
+			atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+			logger.Error("sync remote cache has error", zap.String("key", writes[i].Key), zap.String("error", err.Error()))
+			_this.syncFailed(items[i].key, err)
+			continue
+		}
+		synced[items[i].action] = append(synced[items[i].action], items[i].key)
+		if len(items[i].tags) > 0 {
+			tagged = append(tagged, items[i])
+		}
+	}
+
+	if len(tagged) > 0 && _this.tagStore != nil {
+		_this.addRemoteTags(ctx, tagged)
+	}
+
+	for action, keys := range synced {
+		_this.publishInvalidation(ctx, action, keys...)
+	}
+}
+
+// close stops accepting remote writes, dumps the LRU snapshot, stops the LRU janitor and waits until pending writes
+// are flushed or ctx is done
+func (_this *Client) close(ctx context.Context) error {
+	_this.lock.Lock()
+	if !atomic.CompareAndSwapInt32(&_this.closed, 0, 1) {
+		_this.lock.Unlock()
+		return nil
+	}
+	_this.lock.Unlock()
+
+	defer _this.cancelFunc()
+	defer _this.lru.Close()
+
+	err := _this.saveSnapshot(ctx)
+
+	if !_this.remoteCache {
+		return err
+	}
+
+	close(_this.closing)
+
+	select {
+	case <-_this.drained:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("onecache: drain pending writes has error: %v", ctx.Err())
+	}
+}
@@ -0,0 +1,178 @@
+package onecache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/1infras/go-kit/driver/redis"
+)
+
+func TestEnqueueDropOldest(t *testing.T) {
+	c := &Client{
+		stream:         make(chan item, 2),
+		overflowPolicy: DropOldestOnOverflow,
+		stat:           &stat{},
+	}
+
+	for i := 0; i < 5; i++ {
+		c.enqueue(item{key: fmt.Sprintf("%d", i), action: AddElement})
+	}
+
+	stats := c.stat.snapshot("")
+	assert.Equal(t, uint64(3), stats.DroppedWrites)
+	assert.Equal(t, int64(2), stats.SyncQueueDepth)
+	assert.Equal(t, "3", (<-c.stream).key)
+	assert.Equal(t, "4", (<-c.stream).key)
+}
+
+func TestEnqueueDropOldestDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewFakeStore()
+	assert.Nil(t, store.Set(ctx, "drop_oldest_delete:a", []byte("1"), 0))
+
+	c := &Client{
+		namespace:      "drop_oldest_delete",
+		context:        ctx,
+		remote:         store,
+		stream:         make(chan item, 1),
+		overflowPolicy: DropOldestOnOverflow,
+		stat:           &stat{},
+	}
+
+	// The pending delete is written through instead of being dropped
+	c.enqueue(item{key: "a", action: DeleteElement})
+	c.enqueue(item{key: "b", value: []byte("2"), action: AddElement})
+
+	_, err := store.Get(ctx, "drop_oldest_delete:a")
+	assert.Equal(t, Nil, err)
+	stats := c.stat.snapshot("")
+	assert.Equal(t, uint64(0), stats.DroppedWrites)
+	assert.Equal(t, int64(1), stats.SyncQueueDepth)
+	assert.Equal(t, "b", (<-c.stream).key)
+}
+
+func TestEnqueueBlockDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Nobody consumes the unbuffered stream and the context is done, so the write is dropped instead of blocking
+	c := &Client{
+		context:        ctx,
+		stream:         make(chan item),
+		closing:        make(chan struct{}),
+		overflowPolicy: BlockOnOverflow,
+		stat:           &stat{},
+	}
+
+	c.enqueue(item{key: "a", action: AddElement})
+
+	stats := c.stat.snapshot("")
+	assert.Equal(t, uint64(1), stats.DroppedWrites)
+	assert.Equal(t, int64(0), stats.SyncQueueDepth)
+}
+
+func TestEnqueueWriteThrough(t *testing.T) {
+	ctx := context.Background()
+
+	redisCache, err := redis.NewDefaultRedisUniversalClient()
+	assert.Nil(t, err)
+
+	// Nobody consumes the unbuffered stream so every write overflows
+	c := &Client{
+		namespace:      "redis_cache_write_through",
+		context:        ctx,
+		remote:         NewRedisStore(redisCache),
+		stream:         make(chan item),
+		overflowPolicy: WriteThroughOnOverflow,
+		stat:           &stat{},
+	}
+
+	c.enqueue(item{key: "a", value: []byte("1"), expiration: 3 * time.Second, action: AddElement})
+
+	v, err := redisCache.Get(ctx, "redis_cache_write_through:a").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, "1", v)
+	assert.Equal(t, int64(0), c.stat.snapshot("").SyncQueueDepth)
+}
+
+func TestEnqueueWriteThroughOrder(t *testing.T) {
+	ctx := context.Background()
+	store := NewFakeStore()
+
+	c := &Client{
+		namespace:      "write_through_order",
+		context:        ctx,
+		remote:         store,
+		stream:         make(chan item, 1),
+		syncBatchSize:  10,
+		overflowPolicy: WriteThroughOnOverflow,
+		stat:           &stat{},
+	}
+
+	// The second write overflows and is written before the first one is synced
+	c.enqueue(item{key: "a", value: []byte("1"), action: AddElement})
+	c.enqueue(item{key: "a", value: []byte("2"), action: AddElement})
+	c.syncRemoteCache(ctx, c.nextBatch(nil))
+
+	v, err := store.Get(ctx, "write_through_order:a")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", string(v))
+	assert.Equal(t, int64(0), c.stat.snapshot("").SyncQueueDepth)
+
+	// Writes enqueued after the write through are synced
+	c.enqueue(item{key: "a", value: []byte("3"), action: AddElement})
+	c.syncRemoteCache(ctx, c.nextBatch(nil))
+
+	v, err = store.Get(ctx, "write_through_order:a")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", string(v))
+	assert.Empty(t, c.syncing.writtenThrough)
+}
+
+func TestOneCacheClose(t *testing.T) {
+	ctx := context.Background()
+
+	redisCache, err := redis.NewDefaultRedisUniversalClient()
+	assert.Nil(t, err)
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(3*time.Second),
+		SetSyncQueueSize(1000),
+		SetSyncBatchSize(50),
+		SetRemoteCacheNamespace("redis_cache_close"),
+		SetRemoteCache(redisCache))
+	assert.Nil(t, err)
+
+	keys := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("%d", i)
+		keys = append(keys, "redis_cache_close:"+key)
+		err = oneCache.Set(ctx, key, i, 3)
+		assert.Nil(t, err)
+	}
+
+	closeCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	err = oneCache.Close(closeCtx)
+	assert.Nil(t, err)
+
+	// Every pending write has been flushed
+	assert.Equal(t, int64(0), oneCache.Stats().SyncQueueDepth)
+	existed, err := redisCache.Exists(ctx, keys...).Result()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(200), existed)
+
+	// Writes after closing are kept in LRU only
+	err = oneCache.Set(ctx, "after_close", 1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), oneCache.Stats().DroppedWrites)
+
+	err = oneCache.Close(closeCtx)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,86 @@
+package onecache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrScanNotSupported is returned by stores cannot iterate over their keys
+var ErrScanNotSupported = errors.New("cache: remote store does not support scanning keys")
+
+// RemoteStore is the second layer of onecache, keys are given with the namespace prefix
+type RemoteStore interface {
+	// Get returns Nil if key is not found
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Exists reports whether key holds a value without fetching it
+	Exists(ctx context.Context, key string) (bool, error)
+	// MGet returns values of found keys only, values are still returned along with an error of other keys
+	MGet(ctx context.Context, keys ...string) (map[string][]byte, error)
+	// Set writes value with expiration, 0 means no expiration
+	Set(ctx context.Context, key string, value []byte, expiration time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Scan iterates over keys matching a redis glob pattern
+	Scan(ctx context.Context, match string) KeyIterator
+	Ping(ctx context.Context) error
+}
+
+// RemoteWrite is a set, or a delete if Delete is true, applied to a RemoteStore
+type RemoteWrite struct {
+	Key        string
+	Value      []byte
+	Expiration time.Duration
+	Delete     bool
+}
+
+// BatchWriter is implemented by stores can apply many writes within a round trip, errors are returned per write
+type BatchWriter interface {
+	Write(ctx context.Context, writes []RemoteWrite) []error
+}
+
+// PubSub is implemented by stores can broadcast messages, it is required by invalidation
+type PubSub interface {
+	Publish(ctx context.Context, channel string, message []byte) error
+	// Subscribe returns once the subscription is active, the channel is closed after ctx is done
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+}
+
+// writeRemote applies writes with a single batch if store supports it, or one by one otherwise
+func writeRemote(ctx context.Context, store RemoteStore, writes []RemoteWrite) []error {
+	if batch, ok := store.(BatchWriter); ok {
+		return batch.Write(ctx, writes)
+	}
+
+	errs := make([]error, len(writes))
+	for i, w := range writes {
+		if w.Delete {
+			errs[i] = store.Delete(ctx, w.Key)
+		} else {
+			errs[i] = store.Set(ctx, w.Key, w.Value, w.Expiration)
+		}
+	}
+	return errs
+}
+
+// sliceIterator is a KeyIterator over keys are known upfront
+type sliceIterator struct {
+	keys []string
+	key  string
+	err  error
+}
+
+func (_this *sliceIterator) Next(ctx context.Context) bool {
+	if _this.err != nil || len(_this.keys) == 0 {
+		return false
+	}
+	_this.key, _this.keys = _this.keys[0], _this.keys[1:]
+	return true
+}
+
+func (_this *sliceIterator) Key() string {
+	return _this.key
+}
+
+func (_this *sliceIterator) Err() error {
+	return _this.err
+}
@@ -0,0 +1,322 @@
+package onecache
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeStore is an in-process RemoteStore for tests, instances of OneCache sharing a FakeStore
+// behave as if they share a redis including invalidation
+type FakeStore struct {
+	lock        sync.RWMutex
+	entries     map[string]fakeEntry
+	subscribers map[string][]chan []byte
+}
+
+// fakeEntry is a value, or a set if members is not nil
+type fakeEntry struct {
+	value    []byte
+	members  map[string]struct{}
+	expireAt time.Time
+}
+
+func (_this fakeEntry) expired() bool {
+	return !_this.expireAt.IsZero() && time.Now().After(_this.expireAt)
+}
+
+// NewFakeStore returns an empty FakeStore
+func NewFakeStore() *FakeStore {
+	return &FakeStore{
+		entries:     make(map[string]fakeEntry),
+		subscribers: make(map[string][]chan []byte),
+	}
+}
+
+func (_this *FakeStore) get(key string) ([]byte, bool) {
+	e, ok := _this.entries[key]
+	if !ok || e.expired() || e.members != nil {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (_this *FakeStore) set(key string, value []byte, expiration time.Duration) {
+	e := fakeEntry{
+		value: append([]byte(nil), value...),
+	}
+	if expiration > 0 {
+		e.expireAt = time.Now().Add(expiration)
+	}
+	_this.entries[key] = e
+}
+
+func (_this *FakeStore) Get(ctx context.Context, key string) ([]byte, error) {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	b, ok := _this.get(key)
+	if !ok {
+		return nil, Nil
+	}
+	return b, nil
+}
+
+func (_this *FakeStore) Exists(ctx context.Context, key string) (bool, error) {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	_, ok := _this.get(key)
+	return ok, nil
+}
+
+func (_this *FakeStore) MGet(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if b, ok := _this.get(key); ok {
+			values[key] = b
+		}
+	}
+	return values, nil
+}
+
+func (_this *FakeStore) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	_this.set(key, value, expiration)
+	return nil
+}
+
+func (_this *FakeStore) Delete(ctx context.Context, keys ...string) error {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	for _, key := range keys {
+		delete(_this.entries, key)
+	}
+	return nil
+}
+
+func (_this *FakeStore) Write(ctx context.Context, writes []RemoteWrite) []error {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	for _, w := range writes {
+		if w.Delete {
+			delete(_this.entries, w.Key)
+		} else {
+			_this.set(w.Key, w.Value, w.Expiration)
+		}
+	}
+	return make([]error, len(writes))
+}
+
+// AddTags keeps a tag set alive as long as its longest living member like RedisStore does
+func (_this *FakeStore) AddTags(ctx context.Context, members []TagMember) error {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	for _, m := range members {
+		e, ok := _this.entries[m.Tag]
+		if !ok || e.expired() || e.members == nil {
+			e = fakeEntry{members: make(map[string]struct{})}
+			if m.Expiration > 0 {
+				e.expireAt = time.Now().Add(m.Expiration)
+			}
+		}
+
+		switch expireAt := time.Now().Add(m.Expiration); {
+		case m.Expiration <= 0:
+			e.expireAt = time.Time{}
+		case !e.expireAt.IsZero() && expireAt.After(e.expireAt):
+			e.expireAt = expireAt
+		}
+
+		e.members[m.Key] = struct{}{}
+		_this.entries[m.Tag] = e
+	}
+	return nil
+}
+
+func (_this *FakeStore) Members(ctx context.Context, tag string) ([]string, error) {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	e, ok := _this.entries[tag]
+	if !ok || e.expired() {
+		return nil, nil
+	}
+
+	members := make([]string, 0, len(e.members))
+	for key := range e.members {
+		members = append(members, key)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// Scan returns keys matching match in lexical order
+func (_this *FakeStore) Scan(ctx context.Context, match string) KeyIterator {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	var keys []string
+	for key, e := range _this.entries {
+		if !e.expired() && matchGlob(match, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return &sliceIterator{keys: keys}
+}
+
+func (_this *FakeStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Publish drops the message for subscribers are too far behind like redis does with slow consumers
+func (_this *FakeStore) Publish(ctx context.Context, channel string, message []byte) error {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	for _, ch := range _this.subscribers[channel] {
+		select {
+		case ch <- append([]byte(nil), message...):
+		default:
+		}
+	}
+	return nil
+}
+
+func (_this *FakeStore) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	in := make(chan []byte, 100)
+	out := make(chan []byte)
+
+	_this.lock.Lock()
+	_this.subscribers[channel] = append(_this.subscribers[channel], in)
+	_this.lock.Unlock()
+
+	go func() {
+		defer close(out)
+		defer _this.unsubscribe(channel, in)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message := <-in:
+				select {
+				case out <- message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (_this *FakeStore) unsubscribe(channel string, ch chan []byte) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	subscribers := _this.subscribers[channel]
+	for i := range subscribers {
+		if subscribers[i] == ch {
+			_this.subscribers[channel] = append(subscribers[:i:i], subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// matchGlob reports whether s matches a redis glob pattern supports *, ?, [...], [^...], ranges and \ escapes
+func matchGlob(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := 1
+			for end < len(pattern) && pattern[end] != ']' {
+				if pattern[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(pattern) {
+				return false
+			}
+			if !matchClass(pattern[1:end], s[0]) {
+				return false
+			}
+			pattern = pattern[end:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass reports whether c is in the character class of a [...] glob without brackets
+func matchClass(class string, c byte) bool {
+	negate := len(class) > 0 && class[0] == '^'
+	if negate {
+		class = class[1:]
+	}
+
+	matched := false
+	for i := 0; i < len(class); i++ {
+		lo := class[i]
+		if lo == '\\' && i+1 < len(class) {
+			i++
+			lo = class[i]
+		}
+		hi := lo
+		if i+2 < len(class) && class[i+1] == '-' {
+			hi = class[i+2]
+			i += 2
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+
+	return matched != negate
+}
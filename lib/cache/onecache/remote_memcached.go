@@ -0,0 +1,96 @@
+package onecache
+
+import (
+	"context"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// maxRelativeExpiration is the longest expiration memcached takes as relative seconds,
+// longer ones are sent as unix timestamps
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// MemcachedStore is a RemoteStore speaks the memcached protocol, memcached cannot iterate over keys
+// so Keys and Flush of a OneCache backed by it return ErrScanNotSupported
+type MemcachedStore struct {
+	client *memcache.Client
+}
+
+// NewMemcachedStore returns a MemcachedStore uses client
+func NewMemcachedStore(client *memcache.Client) *MemcachedStore {
+	return &MemcachedStore{
+		client: client,
+	}
+}
+
+// getExpiration converts expiration to memcached seconds, sub second expirations are rounded up
+func getExpiration(expiration time.Duration) int32 {
+	if expiration <= 0 {
+		return 0
+	}
+	if expiration > maxRelativeExpiration {
+		return int32(time.Now().Add(expiration).Unix())
+	}
+	return int32((expiration + time.Second - 1) / time.Second)
+}
+
+func (_this *MemcachedStore) Get(ctx context.Context, key string) ([]byte, error) {
+	it, err := _this.client.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return nil, Nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return it.Value, nil
+}
+
+// Exists gets the key, memcached has no command checks a key without returning its value
+func (_this *MemcachedStore) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := _this.client.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (_this *MemcachedStore) MGet(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	items, err := _this.client.GetMulti(keys)
+
+	values := make(map[string][]byte, len(items))
+	for key, it := range items {
+		values[key] = it.Value
+	}
+	return values, err
+}
+
+func (_this *MemcachedStore) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return _this.client.Set(&memcache.Item{
+		Key:        key,
+		Value:      value,
+		Expiration: getExpiration(expiration),
+	})
+}
+
+func (_this *MemcachedStore) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := _this.client.Delete(key); err != nil && err != memcache.ErrCacheMiss {
+			return err
+		}
+	}
+	return nil
+}
+
+func (_this *MemcachedStore) Scan(ctx context.Context, match string) KeyIterator {
+	return &sliceIterator{err: ErrScanNotSupported}
+}
+
+// Ping gets a key, a miss means the server is reachable
+func (_this *MemcachedStore) Ping(ctx context.Context) error {
+	_, err := _this.client.Get("onecache_ping")
+	if err == memcache.ErrCacheMiss {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,279 @@
+package onecache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	DefaultScanCount = 100
+)
+
+// addTagsScript adds members to a tag set and keeps the set alive as long as its longest living member,
+// ARGV[1] is the expiration of members in milliseconds, 0 means forever
+var addTagsScript = `
+local ttl = redis.call('PTTL', KEYS[1])
+redis.call('SADD', KEYS[1], unpack(ARGV, 2))
+local expiration = tonumber(ARGV[1])
+if expiration <= 0 then
+	redis.call('PERSIST', KEYS[1])
+elseif ttl == -2 or (ttl >= 0 and ttl < expiration) then
+	redis.call('PEXPIRE', KEYS[1], expiration)
+end
+return 1
+`
+
+// RedisStore is a RemoteStore backed by a redis client, cluster and failover clients are supported
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore returns a RedisStore uses client
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{
+		client: client,
+	}
+}
+
+func isRedisNil(err error) bool {
+	return err != nil && err.Error() == redis.Nil.Error()
+}
+
+func (_this *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := _this.client.Get(ctx, key).Bytes()
+	if isRedisNil(err) {
+		return nil, Nil
+	}
+	return b, err
+}
+
+func (_this *RedisStore) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := _this.client.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+// MGet uses a pipeline which works on every kind of redis client, a MGET would fail with cross slot keys on redis cluster
+func (_this *RedisStore) MGet(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	pipe := _this.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+
+	_, err := pipe.Exec(ctx)
+	if isRedisNil(err) {
+		err = nil
+	}
+
+	values := make(map[string][]byte, len(keys))
+	for i, cmd := range cmds {
+		if b, err := cmd.Bytes(); err == nil {
+			values[keys[i]] = b
+		}
+	}
+
+	return values, err
+}
+
+func (_this *RedisStore) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return _this.client.Set(ctx, key, value, expiration).Err()
+}
+
+// Delete unlinks keys without blocking redis, keys of a redis cluster may live in different slots so they are unlinked one by one in a pipeline
+func (_this *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if _, ok := _this.client.(*redis.ClusterClient); !ok {
+		return _this.client.Unlink(ctx, keys...).Err()
+	}
+
+	pipe := _this.client.Pipeline()
+	for _, key := range keys {
+		pipe.Unlink(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Write applies writes with a single pipeline, deletes unlink keys like Delete
+func (_this *RedisStore) Write(ctx context.Context, writes []RemoteWrite) []error {
+	pipe := _this.client.Pipeline()
+	cmds := make([]redis.Cmder, len(writes))
+	for i, w := range writes {
+		if w.Delete {
+			cmds[i] = pipe.Unlink(ctx, w.Key)
+		} else {
+			cmds[i] = pipe.Set(ctx, w.Key, w.Value, w.Expiration)
+		}
+	}
+
+	// Errors are reported per command below
+	_, _ = pipe.Exec(ctx)
+
+	errs := make([]error, len(writes))
+	for i, cmd := range cmds {
+		if err := cmd.Err(); err != nil && !isRedisNil(err) {
+			errs[i] = fmt.Errorf("%s has error: %v", cmd.Name(), err)
+		}
+	}
+	return errs
+}
+
+// Scan iterates over every master of a redis cluster, or the client itself otherwise
+func (_this *RedisStore) Scan(ctx context.Context, match string) KeyIterator {
+	scanners, err := _this.getScanners(ctx)
+
+	return &scanIterator{
+		scanners: scanners,
+		match:    match,
+		err:      err,
+	}
+}
+
+func (_this *RedisStore) Ping(ctx context.Context) error {
+	return _this.client.Ping(ctx).Err()
+}
+
+func (_this *RedisStore) Publish(ctx context.Context, channel string, message []byte) error {
+	return _this.client.Publish(ctx, channel, message).Err()
+}
+
+func (_this *RedisStore) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	pubSub := _this.client.Subscribe(ctx, channel)
+
+	// Wait for the subscription is confirmed so no message is missed after returning
+	if _, err := pubSub.Receive(ctx); err != nil {
+		_ = pubSub.Close()
+		return nil, err
+	}
+
+	ch := make(chan []byte)
+	go func() {
+		defer close(ch)
+		defer pubSub.Close()
+
+		messages := pubSub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case ch <- []byte(message.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// AddTags adds members with a pipeline of scripts, one per tag
+func (_this *RedisStore) AddTags(ctx context.Context, members []TagMember) error {
+	var tags []string
+	keys := make(map[string][]interface{})
+	expirations := make(map[string]time.Duration)
+	for _, m := range members {
+		if _, ok := keys[m.Tag]; !ok {
+			tags = append(tags, m.Tag)
+		}
+		keys[m.Tag] = append(keys[m.Tag], m.Key)
+
+		expiration, ok := expirations[m.Tag]
+		switch {
+		case !ok, m.Expiration <= 0:
+			expirations[m.Tag] = m.Expiration
+		case expiration > 0 && m.Expiration > expiration:
+			expirations[m.Tag] = m.Expiration
+		}
+	}
+
+	pipe := _this.client.Pipeline()
+	for _, tag := range tags {
+		expiration := expirations[tag]
+		if expiration < 0 {
+			expiration = 0
+		}
+		args := append([]interface{}{int64(expiration / time.Millisecond)}, keys[tag]...)
+		pipe.Eval(ctx, addTagsScript, []string{tag}, args...)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (_this *RedisStore) Members(ctx context.Context, tag string) ([]string, error) {
+	return _this.client.SMembers(ctx, tag).Result()
+}
+
+func (_this *RedisStore) getScanners(ctx context.Context) ([]redis.Cmdable, error) {
+	cluster, ok := _this.client.(*redis.ClusterClient)
+	if !ok {
+		return []redis.Cmdable{_this.client}, nil
+	}
+
+	var (
+		lock     sync.Mutex
+		scanners []redis.Cmdable
+	)
+
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		lock.Lock()
+		defer lock.Unlock()
+		scanners = append(scanners, master)
+		return nil
+	})
+
+	return scanners, err
+}
+
+// scanIterator walks SCAN cursors of scanners one after another
+type scanIterator struct {
+	scanners []redis.Cmdable
+	match    string
+
+	iterator *redis.ScanIterator
+	err      error
+}
+
+func (_this *scanIterator) Next(ctx context.Context) bool {
+	for _this.err == nil {
+		if _this.iterator == nil {
+			if len(_this.scanners) == 0 {
+				return false
+			}
+			_this.iterator = _this.scanners[0].Scan(ctx, 0, _this.match, DefaultScanCount).Iterator()
+			_this.scanners = _this.scanners[1:]
+		}
+
+		if _this.iterator.Next(ctx) {
+			return true
+		}
+
+		_this.err = _this.iterator.Err()
+		_this.iterator = nil
+	}
+	return false
+}
+
+func (_this *scanIterator) Key() string {
+	if _this.iterator == nil {
+		return ""
+	}
+	return _this.iterator.Val()
+}
+
+func (_this *scanIterator) Err() error {
+	return _this.err
+}
@@ -0,0 +1,193 @@
+package onecache
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/1infras/go-kit/driver/redis"
+)
+
+func testRemoteStore(t *testing.T, store RemoteStore, scan bool) {
+	ctx := context.Background()
+
+	err := store.Delete(ctx, "remote_store_a", "remote_store_b", "remote_store_c")
+	assert.Nil(t, err)
+
+	_, err = store.Get(ctx, "remote_store_a")
+	assert.Equal(t, Nil, err)
+	existed, err := store.Exists(ctx, "remote_store_a")
+	assert.Nil(t, err)
+	assert.False(t, existed)
+
+	err = store.Set(ctx, "remote_store_a", []byte("1"), 10*time.Second)
+	assert.Nil(t, err)
+	existed, err = store.Exists(ctx, "remote_store_a")
+	assert.Nil(t, err)
+	assert.True(t, existed)
+
+	b, err := store.Get(ctx, "remote_store_a")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), b)
+
+	errs := writeRemote(ctx, store, []RemoteWrite{
+		{Key: "remote_store_b", Value: []byte("2"), Expiration: 10 * time.Second},
+		{Key: "remote_store_c", Value: []byte("3"), Expiration: time.Second},
+		{Key: "remote_store_a", Delete: true},
+	})
+	for _, err := range errs {
+		assert.Nil(t, err)
+	}
+
+	values, err := store.MGet(ctx, "remote_store_a", "remote_store_b", "remote_store_c")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{
+		"remote_store_b": []byte("2"),
+		"remote_store_c": []byte("3"),
+	}, values)
+
+	if scan {
+		var keys []string
+		iterator := store.Scan(ctx, "remote_store_*")
+		for iterator.Next(ctx) {
+			keys = append(keys, iterator.Key())
+		}
+		assert.Nil(t, iterator.Err())
+		sort.Strings(keys)
+		assert.Equal(t, []string{"remote_store_b", "remote_store_c"}, keys)
+	} else {
+		iterator := store.Scan(ctx, "remote_store_*")
+		assert.False(t, iterator.Next(ctx))
+		assert.Equal(t, ErrScanNotSupported, iterator.Err())
+	}
+
+	err = store.Delete(ctx, "remote_store_b", "remote_store_c")
+	assert.Nil(t, err)
+
+	values, err = store.MGet(ctx, "remote_store_b", "remote_store_c")
+	assert.Nil(t, err)
+	assert.Empty(t, values)
+}
+
+func TestFakeStore(t *testing.T) {
+	testRemoteStore(t, NewFakeStore(), true)
+
+	ctx := context.Background()
+	store := NewFakeStore()
+
+	err := store.Set(ctx, "short", []byte("1"), 10*time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = store.Get(ctx, "short")
+	assert.Equal(t, Nil, err)
+}
+
+func TestRedisStore(t *testing.T) {
+	redisCache, err := redis.NewDefaultRedisUniversalClient()
+	assert.Nil(t, err)
+
+	testRemoteStore(t, NewRedisStore(redisCache), true)
+}
+
+func TestMemcachedStore(t *testing.T) {
+	store := NewMemcachedStore(memcache.New("localhost:11211"))
+	if err := store.Ping(context.Background()); err != nil {
+		t.Skipf("memcached is not available: %v", err)
+	}
+
+	testRemoteStore(t, store, false)
+}
+
+func TestMemcachedExpiration(t *testing.T) {
+	assert.Equal(t, int32(0), getExpiration(0))
+	assert.Equal(t, int32(1), getExpiration(10*time.Millisecond))
+	assert.Equal(t, int32(30), getExpiration(30*time.Second))
+
+	absolute := getExpiration(31 * 24 * time.Hour)
+	assert.True(t, int64(absolute) > time.Now().Unix())
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		s       string
+		matched bool
+	}{
+		{"*", "", true},
+		{"a*", "abc", true},
+		{"a*c", "abbbc", true},
+		{"a*c", "abbbd", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"a[bc]d", "acd", true},
+		{"a[^bc]d", "acd", false},
+		{"a[a-c]d", "abd", true},
+		{"a[a-c]d", "add", false},
+		{`a\*`, "a*", true},
+		{`a\*`, "ab", false},
+		{escapePattern("ns[1]_") + "*", "ns[1]_key", true},
+		{escapePattern("ns[1]_") + "*", "ns1_key", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.matched, matchGlob(c.pattern, c.s), "%s %s", c.pattern, c.s)
+	}
+}
+
+func TestOneCacheFakeStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFakeStore()
+
+	newCache := func() OneCache {
+		oneCache, err := NewOneCache(
+			SetContext(ctx),
+			SetExpiration(10*time.Second),
+			SetRemoteCacheNamespace("fake"),
+			SetRemoteStore(store),
+			SetInvalidation(true))
+		assert.Nil(t, err)
+		return oneCache
+	}
+
+	writer, reader := newCache(), newCache()
+
+	err := writer.Set(ctx, "price", 10, -1)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		element, err := reader.Get(ctx, "price")
+		return err == nil && element.String() == "10"
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// The reader keeps the price in its LRU until the writer invalidates it
+	err = writer.Set(ctx, "price", 20, -1)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		element, err := reader.Get(ctx, "price")
+		return err == nil && element.String() == "20"
+	}, 3*time.Second, 10*time.Millisecond)
+
+	var keys []string
+	iterator := reader.Keys(ctx, "")
+	for iterator.Next(ctx) {
+		keys = append(keys, iterator.Key())
+	}
+	assert.Nil(t, iterator.Err())
+	assert.Equal(t, []string{"price"}, keys)
+
+	err = writer.Flush(ctx)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := reader.Get(ctx, "price")
+		return err == Nil
+	}, 3*time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, writer.Close(ctx))
+	assert.Nil(t, reader.Close(ctx))
+}
@@ -1,7 +1,16 @@
 package onecache
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
 )
 
 type Serializer interface {
@@ -18,3 +27,188 @@ func (_this *DefaultSerializer) Encode(value interface{}) ([]byte, error) {
 func (_this *DefaultSerializer) Decode(data []byte, object interface{}) error {
 	return json.Unmarshal(data, object)
 }
+
+// GobSerializer encodes values with encoding/gob, concrete types stored behind interfaces must be registered with gob.Register
+type GobSerializer struct{}
+
+func (_this *GobSerializer) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (_this *GobSerializer) Decode(data []byte, object interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(object)
+}
+
+// MsgpackSerializer encodes values with MessagePack
+type MsgpackSerializer struct{}
+
+func (_this *MsgpackSerializer) Encode(value interface{}) ([]byte, error) {
+	return msgpack.Marshal(value)
+}
+
+func (_this *MsgpackSerializer) Decode(data []byte, object interface{}) error {
+	return msgpack.Unmarshal(data, object)
+}
+
+// ProtoSerializer encodes values with protocol buffers, values and objects must implement proto.Message
+type ProtoSerializer struct{}
+
+func (_this *ProtoSerializer) Encode(value interface{}) ([]byte, error) {
+	m, ok := value.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("value of type %T is not a proto.Message", value)
+	}
+	return proto.Marshal(m)
+}
+
+func (_this *ProtoSerializer) Decode(data []byte, object interface{}) error {
+	m, ok := object.(proto.Message)
+	if !ok {
+		return fmt.Errorf("object of type %T is not a proto.Message", object)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+type CompressionAlgorithm byte
+
+// The algorithm byte is written after compressionMagic in front of every payload of CompressingSerializer
+const (
+	NoCompression CompressionAlgorithm = iota
+	SnappyCompression
+	GzipCompression
+)
+
+const (
+	DefaultCompressionThreshold = 1024
+
+	compressionHeaderSize = 4
+)
+
+// compressionMagic marks a payload of CompressingSerializer, a single header byte would be mistaken for the first byte of
+// msgpack or gob payloads
+var compressionMagic = []byte{0xfe, 'o', 'z'}
+
+// CompressingSerializer wraps a Serializer and compresses payloads larger than Threshold.
+// Payloads are prefixed with the layout: magic(3) | algorithm(1). Data without the header, or with a header
+// it fails to decompress, is passed to the wrapped Serializer as is, so values were written before enabling
+// compression stay readable
+type CompressingSerializer struct {
+	Serializer Serializer
+	Algorithm  CompressionAlgorithm
+	Threshold  int
+}
+
+// NewCompressingSerializer returns a CompressingSerializer with DefaultCompressionThreshold
+func NewCompressingSerializer(serializer Serializer, algorithm CompressionAlgorithm) *CompressingSerializer {
+	return &CompressingSerializer{
+		Serializer: serializer,
+		Algorithm:  algorithm,
+		Threshold:  DefaultCompressionThreshold,
+	}
+}
+
+// compressionHeader returns a buffer starts with the header of algorithm
+func compressionHeader(algorithm CompressionAlgorithm, size int) []byte {
+	buf := make([]byte, compressionHeaderSize, compressionHeaderSize+size)
+	copy(buf, compressionMagic)
+	buf[3] = byte(algorithm)
+	return buf
+}
+
+func (_this *CompressingSerializer) Encode(value interface{}) ([]byte, error) {
+	if _this.Serializer == nil {
+		return nil, fmt.Errorf("serializer must not be empty")
+	}
+
+	data, err := _this.Serializer.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < _this.Threshold {
+		return append(compressionHeader(NoCompression, len(data)), data...), nil
+	}
+
+	switch _this.Algorithm {
+	case SnappyCompression:
+		b := snappy.Encode(nil, data)
+		return append(compressionHeader(SnappyCompression, len(b)), b...), nil
+	case GzipCompression:
+		buf := bytes.NewBuffer(compressionHeader(GzipCompression, len(data)/2))
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case NoCompression:
+		return append(compressionHeader(NoCompression, len(data)), data...), nil
+	default:
+		return nil, fmt.Errorf("compression algorithm %d is not supported", _this.Algorithm)
+	}
+}
+
+func (_this *CompressingSerializer) Decode(data []byte, object interface{}) error {
+	if _this.Serializer == nil {
+		return fmt.Errorf("serializer must not be empty")
+	}
+
+	if len(data) < compressionHeaderSize || !bytes.HasPrefix(data, compressionMagic) {
+		return _this.Serializer.Decode(data, object)
+	}
+
+	b, err := decompress(CompressionAlgorithm(data[3]), data[compressionHeaderSize:])
+	if err != nil {
+		// The header is part of a payload was written without compression
+		if legacyErr := _this.Serializer.Decode(data, object); legacyErr == nil {
+			return nil
+		}
+		return err
+	}
+	return _this.Serializer.Decode(b, object)
+}
+
+// uncompressed returns the payload of data before compression, data without a valid header is returned as is
+func (_this *CompressingSerializer) uncompressed(data []byte) []byte {
+	if len(data) < compressionHeaderSize || !bytes.HasPrefix(data, compressionMagic) {
+		return data
+	}
+	b, err := decompress(CompressionAlgorithm(data[3]), data[compressionHeaderSize:])
+	if err != nil {
+		return data
+	}
+	return b
+}
+
+// decompress returns the payload compressed by algorithm
+func decompress(algorithm CompressionAlgorithm, data []byte) ([]byte, error) {
+	switch algorithm {
+	case NoCompression:
+		return data, nil
+	case SnappyCompression:
+		b, err := snappy.Decode(nil, data)
+		if err != nil {
+			return nil, fmt.Errorf("decompress snappy has error: %v", err)
+		}
+		return b, nil
+	case GzipCompression:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decompress gzip has error: %v", err)
+		}
+		defer r.Close()
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("decompress gzip has error: %v", err)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("compression algorithm %d is not supported", algorithm)
+	}
+}
@@ -0,0 +1,151 @@
+package onecache
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type testProduct struct {
+	ID    int
+	Name  string
+	Price float64
+	Tags  []string
+}
+
+func TestSerializers(t *testing.T) {
+	expected := testProduct{ID: 1, Name: "book", Price: 9.5, Tags: []string{"paper"}}
+
+	serializers := map[string]Serializer{
+		"json":    &DefaultSerializer{},
+		"gob":     &GobSerializer{},
+		"msgpack": &MsgpackSerializer{},
+		"snappy":  NewCompressingSerializer(&MsgpackSerializer{}, SnappyCompression),
+		"gzip":    NewCompressingSerializer(&DefaultSerializer{}, GzipCompression),
+	}
+
+	for name, serializer := range serializers {
+		t.Run(name, func(t *testing.T) {
+			b, err := serializer.Encode(expected)
+			assert.Nil(t, err)
+
+			var actual testProduct
+			err = serializer.Decode(b, &actual)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestProtoSerializer(t *testing.T) {
+	serializer := &ProtoSerializer{}
+
+	b, err := serializer.Encode(&wrapperspb.StringValue{Value: "hello"})
+	assert.Nil(t, err)
+
+	actual := &wrapperspb.StringValue{}
+	err = serializer.Decode(b, actual)
+	assert.Nil(t, err)
+	assert.True(t, proto.Equal(&wrapperspb.StringValue{Value: "hello"}, actual))
+
+	_, err = serializer.Encode("hello")
+	assert.NotNil(t, err)
+}
+
+func TestCompressingSerializer(t *testing.T) {
+	large := strings.Repeat("price", 1000)
+
+	for _, algorithm := range []CompressionAlgorithm{SnappyCompression, GzipCompression} {
+		serializer := NewCompressingSerializer(&DefaultSerializer{}, algorithm)
+
+		b, err := serializer.Encode(large)
+		assert.Nil(t, err)
+		assert.Equal(t, append(compressionMagic, byte(algorithm)), b[:compressionHeaderSize])
+		assert.Less(t, len(b), len(large))
+
+		var actual string
+		err = serializer.Decode(b, &actual)
+		assert.Nil(t, err)
+		assert.Equal(t, large, actual)
+
+		// Small payloads are not compressed
+		b, err = serializer.Encode("small")
+		assert.Nil(t, err)
+		assert.Equal(t, append(compressionMagic, byte(NoCompression)), b[:compressionHeaderSize])
+
+		err = serializer.Decode(b, &actual)
+		assert.Nil(t, err)
+		assert.Equal(t, "small", actual)
+
+		// Payloads were written without a header are still readable
+		err = serializer.Decode([]byte(`"legacy"`), &actual)
+		assert.Nil(t, err)
+		assert.Equal(t, "legacy", actual)
+	}
+}
+
+func TestCompressedElementString(t *testing.T) {
+	serializer := NewCompressingSerializer(&DefaultSerializer{}, GzipCompression)
+	serializer.Threshold = 0
+
+	b, err := serializer.Encode(testProduct{ID: 1, Name: "book"})
+	assert.Nil(t, err)
+
+	// A value is not a string is returned as its plain text rather than the compressed payload
+	e := &element{value: b, serializer: serializer}
+	assert.Equal(t, `{"ID":1,"Name":"book","Price":0,"Tags":null}`, strings.TrimSpace(e.String()))
+}
+
+func TestCompressingSerializerLegacy(t *testing.T) {
+	// Payloads of msgpack and gob start with bytes were used as one byte headers
+	for name, serializer := range map[string]Serializer{
+		"msgpack": &MsgpackSerializer{},
+		"gob":     &GobSerializer{},
+	} {
+		compressing := NewCompressingSerializer(serializer, SnappyCompression)
+		for _, value := range []int{0, 1, 2} {
+			b, err := serializer.Encode(value)
+			assert.Nil(t, err, name)
+
+			var actual int
+			err = compressing.Decode(b, &actual)
+			assert.Nil(t, err, name)
+			assert.Equal(t, value, actual, name)
+		}
+	}
+}
+
+func TestOneCacheWithSerializer(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(3*time.Second),
+		SetSerializer(NewCompressingSerializer(&GobSerializer{}, SnappyCompression)))
+	assert.Nil(t, err)
+
+	err = oneCache.Set(ctx, "count", 10, 3)
+	assert.Nil(t, err)
+
+	v, err := oneCache.Get(ctx, "count")
+	assert.Nil(t, err)
+
+	count, err := v.Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 10, count)
+
+	blob := bytes.Repeat([]byte("a"), 4096)
+	err = oneCache.Set(ctx, "blob", blob, 3)
+	assert.Nil(t, err)
+
+	var actual []byte
+	err = oneCache.GetInto(ctx, "blob", &actual)
+	assert.Nil(t, err)
+	assert.Equal(t, blob, actual)
+}
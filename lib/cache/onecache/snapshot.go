@@ -0,0 +1,183 @@
+package onecache
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/1infras/go-kit/logger"
+)
+
+// A snapshot starts with the magic, the version, the time it is taken in unix nanoseconds and the tags of keys
+// encoded as JSON with their length, followed by the snapshot of LRU
+const (
+	snapshotMagic   = "OCSN"
+	snapshotVersion = 1
+	// maxSnapshotTagsLength bounds the length of tags so a corrupted snapshot does not allocate too much
+	maxSnapshotTagsLength = 1 << 30
+)
+
+// SetSnapshotFile warms LRU from the snapshot in path when the cache is created and dumps LRU to path when it is closed,
+// so a restarted instance does not start cold. Items keep their remaining expiration and their tags, a missing or
+// invalid snapshot is skipped.
+// Invalidations published while the instance is down are missed, so with SetInvalidation the snapshot is only
+// restored if SetSnapshotMaxAge bounds how stale it may be
+func SetSnapshotFile(path string) ClientOptionFunc {
+	return func(c *Client) error {
+		if path == "" {
+			return fmt.Errorf("snapshot file must not be empty")
+		}
+		c.snapshotFile = path
+		return nil
+	}
+}
+
+// SetSnapshotMaxAge skips snapshots were taken more than maxAge ago, values older than maxAge may have been
+// overwritten or deleted by other instances
+func SetSnapshotMaxAge(maxAge time.Duration) ClientOptionFunc {
+	return func(c *Client) error {
+		if maxAge <= 0 {
+			return fmt.Errorf("snapshot max age must be greater than 0")
+		}
+		c.snapshotMaxAge = maxAge
+		return nil
+	}
+}
+
+// loadSnapshot restores LRU and its tags from the snapshot file if it exists and is recent enough
+func (_this *Client) loadSnapshot(ctx context.Context) {
+	if _this.snapshotFile == "" {
+		return
+	}
+
+	if _this.invalidation && _this.snapshotMaxAge <= 0 {
+		logger.Info("snapshot of cache is skipped since invalidations may have been missed", zap.String("namespace", _this.namespace))
+		return
+	}
+
+	f, err := os.Open(_this.snapshotFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		logger.Warn("open snapshot of cache has error", zap.String("namespace", _this.namespace), zap.String("error", err.Error()))
+		return
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	takenAt, tags, err := readSnapshotHeader(r)
+	if err != nil {
+		logger.Warn("restore snapshot of cache has error", zap.String("namespace", _this.namespace), zap.String("error", err.Error()))
+		return
+	}
+
+	if age := time.Since(takenAt); _this.snapshotMaxAge > 0 && age > _this.snapshotMaxAge {
+		logger.Info("snapshot of cache is skipped since it is too old", zap.String("namespace", _this.namespace), zap.String("age", age.String()))
+		return
+	}
+
+	restored, err := _this.lru.Restore(ctx, r)
+	if err != nil {
+		logger.Warn("restore snapshot of cache has error", zap.String("namespace", _this.namespace), zap.String("error", err.Error()))
+	}
+
+	// Tags of keys are expired or were not restored are dropped
+	for key, keyTags := range tags {
+		if _this.lru.Contains(ctx, key) {
+			_this.tagLocal(key, keyTags)
+		}
+	}
+
+	logger.Info("cache is warmed from snapshot", zap.String("namespace", _this.namespace), zap.Int("items", restored))
+}
+
+// saveSnapshot dumps LRU and its tags to a temporary file then renames it to the snapshot file, so the previous snapshot
+// is kept if the dump fails
+func (_this *Client) saveSnapshot(ctx context.Context) error {
+	if _this.snapshotFile == "" {
+		return nil
+	}
+
+	tmp := _this.snapshotFile + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("onecache: create snapshot has error: %v", err)
+	}
+
+	if err := _this.writeSnapshot(ctx, f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("onecache: write snapshot has error: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("onecache: write snapshot has error: %v", err)
+	}
+
+	if err := os.Rename(tmp, _this.snapshotFile); err != nil {
+		return fmt.Errorf("onecache: write snapshot has error: %v", err)
+	}
+	return nil
+}
+
+func (_this *Client) writeSnapshot(ctx context.Context, w io.Writer) error {
+	_this.tagLock.Lock()
+	tags, err := json.Marshal(_this.keyTags)
+	_this.tagLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 0, len(snapshotMagic)+1+2*binary.MaxVarintLen64+len(tags))
+	header = append(header, snapshotMagic...)
+	header = append(header, snapshotVersion)
+	buf := make([]byte, binary.MaxVarintLen64)
+	header = append(header, buf[:binary.PutVarint(buf, time.Now().UnixNano())]...)
+	header = append(header, buf[:binary.PutUvarint(buf, uint64(len(tags)))]...)
+	header = append(header, tags...)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	return _this.lru.Snapshot(ctx, w)
+}
+
+// readSnapshotHeader reads the time a snapshot is taken and the tags of its keys, r is left at the snapshot of LRU
+func readSnapshotHeader(r *bufio.Reader) (time.Time, map[string][]string, error) {
+	magic := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic[:len(snapshotMagic)]) != snapshotMagic {
+		return time.Time{}, nil, fmt.Errorf("invalid snapshot format")
+	}
+	if magic[len(snapshotMagic)] != snapshotVersion {
+		return time.Time{}, nil, fmt.Errorf("snapshot version %d is not supported", magic[len(snapshotMagic)])
+	}
+
+	takenAt, err := binary.ReadVarint(r)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid snapshot format: %v", err)
+	}
+
+	length, err := binary.ReadUvarint(r)
+	if err != nil || length > maxSnapshotTagsLength {
+		return time.Time{}, nil, fmt.Errorf("invalid snapshot format: tags length %d", length)
+	}
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid snapshot format: %v", err)
+	}
+
+	var tags map[string][]string
+	if err := json.Unmarshal(b, &tags); err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid snapshot format: %v", err)
+	}
+
+	return time.Unix(0, takenAt), tags, nil
+}
@@ -0,0 +1,128 @@
+package onecache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneCacheSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path))
+	assert.Nil(t, err)
+
+	assert.Nil(t, oneCache.Set(ctx, "a", 1, -1))
+	assert.Nil(t, oneCache.Set(ctx, "b", "b", 10))
+	assert.Nil(t, oneCache.Set(ctx, "expired", 1, 1))
+	assert.Nil(t, oneCache.Close(ctx))
+
+	time.Sleep(1100 * time.Millisecond)
+
+	// The restarted cache serves the keys from LRU without the remote cache
+	warmed, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path))
+	assert.Nil(t, err)
+
+	element, err := warmed.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", element.String())
+	element, err = warmed.Get(ctx, "b")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", element.String())
+	_, err = warmed.Get(ctx, "expired")
+	assert.Equal(t, Nil, err)
+
+	stats := warmed.Stats()
+	assert.Equal(t, uint64(2), stats.LocalHits)
+	assert.Equal(t, 2, stats.LocalItems)
+
+	// An invalid snapshot is skipped
+	assert.Nil(t, os.WriteFile(path, []byte("invalid"), 0600))
+	cold, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cold.Stats().LocalItems)
+
+	// The snapshot can not be written in a missing directory
+	broken, err := NewOneCache(SetContext(ctx), SetSnapshotFile(filepath.Join(path, "missing", "cache.snapshot")))
+	assert.Nil(t, err)
+	assert.NotNil(t, broken.Close(ctx))
+
+	_, err = NewOneCache(SetSnapshotFile(""))
+	assert.NotNil(t, err)
+}
+
+func TestOneCacheSnapshotTags(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path))
+	assert.Nil(t, err)
+	assert.Nil(t, oneCache.SetWithTags(ctx, "a", 1, -1, "users"))
+	assert.Nil(t, oneCache.Set(ctx, "b", 1, -1))
+	assert.Nil(t, oneCache.Close(ctx))
+
+	// Tags of restored keys are kept, so invalidating a tag evicts them
+	warmed, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path))
+	assert.Nil(t, err)
+	assert.Nil(t, warmed.InvalidateTag(ctx, "users"))
+	_, err = warmed.Get(ctx, "a")
+	assert.Equal(t, Nil, err)
+	_, err = warmed.Get(ctx, "b")
+	assert.Nil(t, err)
+}
+
+func TestOneCacheSnapshotMaxAge(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path))
+	assert.Nil(t, err)
+	assert.Nil(t, oneCache.Set(ctx, "a", 1, -1))
+	assert.Nil(t, oneCache.Close(ctx))
+
+	warmed, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path), SetSnapshotMaxAge(time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, warmed.Stats().LocalItems)
+
+	time.Sleep(20 * time.Millisecond)
+
+	// A snapshot older than the max age is skipped
+	cold, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path), SetSnapshotMaxAge(10*time.Millisecond))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cold.Stats().LocalItems)
+
+	_, err = NewOneCache(SetSnapshotMaxAge(0))
+	assert.NotNil(t, err)
+}
+
+func TestOneCacheSnapshotInvalidation(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	store := NewFakeStore()
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetSnapshotFile(path))
+	assert.Nil(t, err)
+	assert.Nil(t, oneCache.Set(ctx, "a", 1, -1))
+	assert.Nil(t, oneCache.Close(ctx))
+
+	newCache := func(opts ...ClientOptionFunc) OneCache {
+		c, err := NewOneCache(append([]ClientOptionFunc{
+			SetContext(ctx),
+			SetSnapshotFile(path),
+			SetRemoteCacheNamespace("snapshot_invalidation"),
+			SetRemoteStore(store),
+			SetInvalidation(true),
+		}, opts...)...)
+		assert.Nil(t, err)
+		return c
+	}
+
+	// Invalidations may have been missed while the instance was down, so the snapshot is skipped without a max age
+	assert.Equal(t, 0, newCache().Stats().LocalItems)
+	assert.Equal(t, 1, newCache(SetSnapshotMaxAge(time.Minute)).Stats().LocalItems)
+}
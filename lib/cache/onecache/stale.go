@@ -0,0 +1,121 @@
+package onecache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/1infras/go-kit/logger"
+)
+
+// Refresher is used to reload the value of a stale key in background
+type Refresher func(ctx context.Context, key string) (interface{}, error)
+
+const (
+	envelopeVersion    byte = 1
+	envelopeHeaderSize      = 20
+)
+
+// envelopeMagic marks a remote value carries soft and hard expiration timestamps
+var envelopeMagic = []byte{0xff, 'o', 'c'}
+
+// SetSoftExpiration makes entries stale after softExpiration, a stale entry is still returned by Get
+// and triggers a single background refresh until it reaches its hard expiration.
+// Remote values carry their soft expiration, so instances sharing a namespace are expected to share this setting
+func SetSoftExpiration(softExpiration time.Duration) ClientOptionFunc {
+	return func(c *Client) error {
+		if softExpiration <= 0 {
+			return fmt.Errorf("soft expiration must be greater than 0")
+		}
+		c.softExpiration = softExpiration
+		return nil
+	}
+}
+
+// SetRefresher registers the refresher is called when a stale key is read, refreshed values are written with the default expiration
+func SetRefresher(refresher Refresher) ClientOptionFunc {
+	return func(c *Client) error {
+		if refresher == nil {
+			return fmt.Errorf("refresher must not be empty")
+		}
+		c.refresher = refresher
+		return nil
+	}
+}
+
+// getSoftExpiration returns the soft expiration of an entry with expiration, 0 means the entry is never stale
+func (_this *Client) getSoftExpiration(expiration time.Duration) time.Duration {
+	if _this.softExpiration <= 0 {
+		return 0
+	}
+	if expiration > 0 && _this.softExpiration >= expiration {
+		return 0
+	}
+	return _this.softExpiration
+}
+
+// wrapEnvelope prefixes b with the layout: magic(3) | version(1) | soft expire(8) | hard expire(8)
+// timestamps are unix nanoseconds, 0 means no expiration
+func wrapEnvelope(b []byte, softExpiration, expiration time.Duration) []byte {
+	now := time.Now()
+	buf := make([]byte, envelopeHeaderSize, envelopeHeaderSize+len(b))
+	copy(buf, envelopeMagic)
+	buf[3] = envelopeVersion
+	if softExpiration > 0 {
+		binary.BigEndian.PutUint64(buf[4:12], uint64(now.Add(softExpiration).UnixNano()))
+	}
+	if expiration > 0 {
+		binary.BigEndian.PutUint64(buf[12:20], uint64(now.Add(expiration).UnixNano()))
+	}
+	return append(buf, b...)
+}
+
+// unwrapEnvelope returns the payload of b and whether it is stale, values without an envelope are never stale
+func unwrapEnvelope(b []byte) ([]byte, bool) {
+	if len(b) < envelopeHeaderSize || !bytes.HasPrefix(b, envelopeMagic) || b[3] != envelopeVersion {
+		return b, false
+	}
+
+	softExpire := int64(binary.BigEndian.Uint64(b[4:12]))
+	stale := softExpire > 0 && time.Now().UnixNano() > softExpire
+
+	return b[envelopeHeaderSize:], stale
+}
+
+// unwrap returns the payload of a remote value and whether it is stale, remote values are only wrapped
+// with soft expiration so payloads look like an envelope are left intact without it
+func (_this *Client) unwrap(b []byte) ([]byte, bool) {
+	if _this.softExpiration <= 0 {
+		return b, false
+	}
+	return unwrapEnvelope(b)
+}
+
+// refresh reloads a stale key with the refresher in background, only one refresh runs per key at a time
+func (_this *Client) refresh(key string) {
+	if _this.refresher == nil {
+		return
+	}
+
+	if _, refreshing := _this.refreshing.LoadOrStore(key, struct{}{}); refreshing {
+		return
+	}
+
+	go func(ctx context.Context) {
+		defer _this.refreshing.Delete(key)
+
+		value, err := _this.refresher(ctx, key)
+		if err != nil {
+			logger.Warn("refresh stale cache has error", zap.String("namespace", _this.namespace), zap.String("key", key), zap.String("error", err.Error()))
+			return
+		}
+
+		if err := _this.set(ctx, key, value, -1); err != nil {
+			logger.Warn("refresh stale cache has error", zap.String("namespace", _this.namespace), zap.String("key", key), zap.String("error", err.Error()))
+		}
+	}(_this.context)
+}
@@ -1,29 +1,162 @@
 package onecache
 
-import "time"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type stat struct {
 	totalOperations uint32
 
-	totalHits   uint32
-	totalMisses uint32
+	totalLocalHits  uint32
+	totalRemoteHits uint32
+	totalMisses     uint32
+	// totalNegativeHits counts reads are served by a cached not found entry
+	totalNegativeHits uint32
 
 	totalReads  uint32
 	totalWrites uint32
 
+	totalEvictions     uint32
+	totalExpirations   uint32
+	totalRemoteErrors  uint32
+	totalDroppedWrites uint32
+
+	// timeStart is unix nanoseconds
 	timeStart int64
 
 	totalReadBytes  int64
 	totalWriteBytes int64
+
+	// pendingWrites is the number of items are waiting to be synced to the remote cache
+	pendingWrites int64
+
+	// window holds the counters at the last reset, counters are exported to prometheus so they only grow
+	// and reset only moves the window of the text report
+	windowLock sync.Mutex
+	window     StatsSnapshot
 }
 
+// StatsSnapshot is a point in time copy of the statistics of a OneCache
+type StatsSnapshot struct {
+	Namespace string
+	Since     time.Time
+
+	Operations uint64
+	Reads      uint64
+	Writes     uint64
+
+	Hits       uint64
+	LocalHits  uint64
+	RemoteHits uint64
+	Misses     uint64
+	// NegativeHits are reads answered with Nil by a cached not found entry, they are neither hits nor misses
+	NegativeHits uint64
+
+	// HitRatio is hits over reads, LocalHitRatio and RemoteHitRatio are the share of reads served by each layer
+	HitRatio       float64
+	LocalHitRatio  float64
+	RemoteHitRatio float64
+
+	ReadBytes  int64
+	WriteBytes int64
+
+	ReadsPerSecond  float64
+	WritesPerSecond float64
+
+	// Evictions are items evicted from LRU for capacity, Expirations are items removed from LRU after they expired
+	Evictions      uint64
+	Expirations    uint64
+	RemoteErrors   uint64
+	DroppedWrites  uint64
+	SyncQueueDepth int64
+
+	// LocalBytes is the total length of values in LRU, MaxLocalBytes is 0 if LRU is bounded by items only
+	LocalItems    int
+	LocalBytes    int64
+	MaxLocalBytes int64
+}
+
+// reset starts a new window of the text report
 func (_this *stat) reset() {
-	_this.totalOperations = 0
-	_this.totalHits = 0
-	_this.totalMisses = 0
-	_this.totalReads = 0
-	_this.totalWrites = 0
-	_this.timeStart = time.Now().Unix()
-	_this.totalReadBytes = 0
-	_this.totalWriteBytes = 0
-}
\ No newline at end of file
+	window := _this.snapshot("")
+	window.Since = time.Now()
+
+	_this.windowLock.Lock()
+	_this.window = window
+	_this.windowLock.Unlock()
+}
+
+// snapshot returns the counters since the cache is created
+func (_this *stat) snapshot(namespace string) StatsSnapshot {
+	s := StatsSnapshot{
+		Namespace:      namespace,
+		Since:          time.Unix(0, atomic.LoadInt64(&_this.timeStart)),
+		Operations:     uint64(atomic.LoadUint32(&_this.totalOperations)),
+		Reads:          uint64(atomic.LoadUint32(&_this.totalReads)),
+		Writes:         uint64(atomic.LoadUint32(&_this.totalWrites)),
+		LocalHits:      uint64(atomic.LoadUint32(&_this.totalLocalHits)),
+		RemoteHits:     uint64(atomic.LoadUint32(&_this.totalRemoteHits)),
+		Misses:         uint64(atomic.LoadUint32(&_this.totalMisses)),
+		NegativeHits:   uint64(atomic.LoadUint32(&_this.totalNegativeHits)),
+		ReadBytes:      atomic.LoadInt64(&_this.totalReadBytes),
+		WriteBytes:     atomic.LoadInt64(&_this.totalWriteBytes),
+		Evictions:      uint64(atomic.LoadUint32(&_this.totalEvictions)),
+		Expirations:    uint64(atomic.LoadUint32(&_this.totalExpirations)),
+		RemoteErrors:   uint64(atomic.LoadUint32(&_this.totalRemoteErrors)),
+		DroppedWrites:  uint64(atomic.LoadUint32(&_this.totalDroppedWrites)),
+		SyncQueueDepth: atomic.LoadInt64(&_this.pendingWrites),
+	}
+
+	s.derive()
+	return s
+}
+
+// windowSnapshot returns the counters since the last reset, or since the cache is created if it is never reset
+func (_this *stat) windowSnapshot(namespace string) StatsSnapshot {
+	_this.windowLock.Lock()
+	w := _this.window
+	_this.windowLock.Unlock()
+
+	s := _this.snapshot(namespace)
+	if w.Since.IsZero() {
+		return s
+	}
+
+	s.Since = w.Since
+	s.Operations -= w.Operations
+	s.Reads -= w.Reads
+	s.Writes -= w.Writes
+	s.LocalHits -= w.LocalHits
+	s.RemoteHits -= w.RemoteHits
+	s.Misses -= w.Misses
+	s.NegativeHits -= w.NegativeHits
+	s.ReadBytes -= w.ReadBytes
+	s.WriteBytes -= w.WriteBytes
+	s.Evictions -= w.Evictions
+	s.Expirations -= w.Expirations
+	s.RemoteErrors -= w.RemoteErrors
+	s.DroppedWrites -= w.DroppedWrites
+
+	s.derive()
+	return s
+}
+
+// derive computes hits, ratios and rates from the counters
+func (s *StatsSnapshot) derive() {
+	s.Hits = s.LocalHits + s.RemoteHits
+	s.HitRatio, s.LocalHitRatio, s.RemoteHitRatio = 0, 0, 0
+	s.ReadsPerSecond, s.WritesPerSecond = 0, 0
+
+	if s.Reads > 0 {
+		s.HitRatio = float64(s.Hits) / float64(s.Reads)
+		s.LocalHitRatio = float64(s.LocalHits) / float64(s.Reads)
+		s.RemoteHitRatio = float64(s.RemoteHits) / float64(s.Reads)
+	}
+
+	if duration := time.Since(s.Since).Seconds(); duration > 0 {
+		s.ReadsPerSecond = float64(s.Reads) / duration
+		s.WritesPerSecond = float64(s.Writes) / duration
+	}
+}
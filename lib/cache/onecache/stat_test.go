@@ -0,0 +1,97 @@
+package onecache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneCacheStats(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(3*time.Second),
+		SetMaxItems(2),
+		SetRemoteCacheNamespace("stats"))
+	assert.Nil(t, err)
+
+	for _, key := range []string{"a", "b", "c"} {
+		err = oneCache.Set(ctx, key, key, 3)
+		assert.Nil(t, err)
+	}
+
+	for _, key := range []string{"b", "c", "a", "d"} {
+		_, _ = oneCache.Get(ctx, key)
+	}
+
+	stats := oneCache.Stats()
+	assert.Equal(t, "stats", stats.Namespace)
+	assert.Equal(t, uint64(3), stats.Writes)
+	assert.Equal(t, uint64(4), stats.Reads)
+	assert.Equal(t, uint64(7), stats.Operations)
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.LocalHits)
+	assert.Equal(t, uint64(0), stats.RemoteHits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 0.5, stats.HitRatio)
+	assert.Equal(t, 0.5, stats.LocalHitRatio)
+	assert.Equal(t, int64(9), stats.WriteBytes)
+	assert.Equal(t, int64(6), stats.ReadBytes)
+	assert.Equal(t, 2, stats.LocalItems)
+	assert.Equal(t, int64(6), stats.LocalBytes)
+
+	// Reads and writes per second must not be swapped
+	assert.Greater(t, stats.ReadsPerSecond, stats.WritesPerSecond)
+	assert.InDelta(t, 4.0/3.0, stats.ReadsPerSecond/stats.WritesPerSecond, 0.0001)
+
+	// Flush resets the report but not the counters exported to prometheus
+	err = oneCache.Flush(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), oneCache.Stats().Operations)
+	assert.Contains(t, oneCache.Report(ctx), "Total ops: 0,")
+
+	_, _ = oneCache.Get(ctx, "a")
+	assert.Equal(t, uint64(5), oneCache.Stats().Reads)
+	assert.Contains(t, oneCache.Report(ctx), "Total read ops: 1,")
+}
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetRemoteCacheNamespace("collector"))
+	assert.Nil(t, err)
+
+	err = oneCache.Set(ctx, "a", 1, 3)
+	assert.Nil(t, err)
+	_, _ = oneCache.Get(ctx, "a")
+	_, _ = oneCache.Get(ctx, "b")
+
+	registry := prometheus.NewPedanticRegistry()
+	err = registry.Register(NewCollector(oneCache))
+	assert.Nil(t, err)
+
+	expected := `
+		# HELP onecache_hits_total Total number of hits by layer.
+		# TYPE onecache_hits_total counter
+		onecache_hits_total{layer="local",namespace="collector"} 1
+		onecache_hits_total{layer="remote",namespace="collector"} 0
+		# HELP onecache_misses_total Total number of misses.
+		# TYPE onecache_misses_total counter
+		onecache_misses_total{namespace="collector"} 1
+		# HELP onecache_reads_total Total number of read operations.
+		# TYPE onecache_reads_total counter
+		onecache_reads_total{namespace="collector"} 2
+	`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"onecache_hits_total", "onecache_misses_total", "onecache_reads_total")
+	assert.Nil(t, err)
+}
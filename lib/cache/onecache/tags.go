@@ -0,0 +1,203 @@
+package onecache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/1infras/go-kit/logger"
+)
+
+// tagKeyPrefix marks the remote sets hold keys of a tag, they are hidden from Keys
+const tagKeyPrefix = "#tag:"
+
+// TagMember adds Key to the set of Tag, the set must live at least Expiration, 0 means forever
+type TagMember struct {
+	Tag        string
+	Key        string
+	Expiration time.Duration
+}
+
+// TagStore is implemented by stores can keep tag membership, it is required by tags with a remote cache
+type TagStore interface {
+	AddTags(ctx context.Context, members []TagMember) error
+	Members(ctx context.Context, tag string) ([]string, error)
+}
+
+func (_this *Client) getTagKey(tag string) string {
+	return _this.getRemoteKey(tagKeyPrefix + tag)
+}
+
+// tagLocal replaces the tags of key in the local index
+func (_this *Client) tagLocal(key string, tags []string) {
+	_this.tagLock.Lock()
+	defer _this.tagLock.Unlock()
+
+	_this.untag(key)
+
+	if len(tags) == 0 {
+		return
+	}
+
+	for _, tag := range tags {
+		keys, ok := _this.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			_this.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	_this.keyTags[key] = tags
+}
+
+// untag removes key from the local index, it must be called with the tag lock held
+func (_this *Client) untag(key string) {
+	for _, tag := range _this.keyTags[key] {
+		delete(_this.tags[tag], key)
+		if len(_this.tags[tag]) == 0 {
+			delete(_this.tags, tag)
+		}
+	}
+	delete(_this.keyTags, key)
+}
+
+func (_this *Client) setWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags []string) error {
+	if _this.remoteCache && _this.tagStore == nil {
+		return fmt.Errorf("remote store does not support tags")
+	}
+
+	return _this.set(ctx, key, value, expiration, tags...)
+}
+
+func (_this *Client) invalidateTag(ctx context.Context, tag string) error {
+	defer atomic.AddUint32(&_this.stat.totalOperations, 1)
+
+	if _this.remoteCache && _this.tagStore == nil {
+		return fmt.Errorf("remote store does not support tags")
+	}
+
+	_this.tagLock.Lock()
+	keys := make([]string, 0, len(_this.tags[tag]))
+	for key := range _this.tags[tag] {
+		keys = append(keys, key)
+	}
+	_this.tagLock.Unlock()
+
+	// LRU calls onEvicted for every removed key so the local index is cleaned up
+	for _, key := range keys {
+		_this.lru.Remove(ctx, key)
+	}
+
+	if _this.remoteCache {
+		_this.enqueue(item{
+			key:    tag,
+			action: InvalidateTagElement,
+		})
+	}
+
+	return nil
+}
+
+// addRemoteTags adds keys of synced items to the remote sets of their tags
+func (_this *Client) addRemoteTags(ctx context.Context, items []item) {
+	var members []TagMember
+	for _, it := range items {
+		for _, tag := range it.tags {
+			members = append(members, TagMember{
+				Tag:        _this.getTagKey(tag),
+				Key:        it.key,
+				Expiration: it.expiration,
+			})
+		}
+	}
+
+	if len(members) == 0 {
+		return
+	}
+
+	if err := _this.tagStore.AddTags(ctx, members); err != nil {
+		atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+		logger.Error("add tags to remote cache has error", zap.String("namespace", _this.namespace), zap.String("error", err.Error()))
+	}
+}
+
+// invalidateRemoteTag deletes every key of tag and the tag itself from the remote cache
+func (_this *Client) invalidateRemoteTag(ctx context.Context, tag string) {
+	tagKey := _this.getTagKey(tag)
+
+	keys, err := _this.tagStore.Members(ctx, tagKey)
+	if err != nil {
+		atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+		logger.Error("get tag members from remote cache has error", zap.String("tag", tag), zap.String("error", err.Error()))
+		return
+	}
+
+	for start := 0; start < len(keys); start += DefaultFlushBatchSize {
+		end := start + DefaultFlushBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		batch := make([]string, 0, end-start)
+		for _, key := range keys[start:end] {
+			batch = append(batch, _this.getRemoteKey(key))
+		}
+
+		if err := _this.remote.Delete(ctx, batch...); err != nil {
+			atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+			logger.Error("invalidate tag in remote cache has error", zap.String("tag", tag), zap.String("error", err.Error()))
+			return
+		}
+	}
+
+	if err := _this.remote.Delete(ctx, tagKey); err != nil {
+		atomic.AddUint32(&_this.stat.totalRemoteErrors, 1)
+		logger.Error("invalidate tag in remote cache has error", zap.String("tag", tag), zap.String("error", err.Error()))
+		return
+	}
+
+	if len(keys) > 0 {
+		_this.publishInvalidation(ctx, DeleteElement, keys...)
+	}
+}
+
+// tagFilterIterator hides the sets of tags
+type tagFilterIterator struct {
+	KeyIterator
+}
+
+func (_this *tagFilterIterator) Next(ctx context.Context) bool {
+	for _this.KeyIterator.Next(ctx) {
+		if !strings.HasPrefix(_this.Key(), tagKeyPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWithTags sets the key like Set and attaches tags to it, InvalidateTag removes every key carrying a tag
+func (_this *Client) SetWithTags(ctx context.Context, key string, value interface{}, expiration int, tags ...string) (err error) {
+	if expiration < 0 {
+		expiration = -1
+	}
+
+	err = _this.process(ctx, "set_with_tags", key, func(ctx context.Context) error {
+		return _this.setWithTags(ctx, key, value, time.Second*time.Duration(expiration), tags)
+	})
+
+	return
+}
+
+// InvalidateTag removes every key carrying tag from LRU and the remote cache, the remote cache is updated
+// in order with pending writes so keys are set with the tag before are removed as well
+func (_this *Client) InvalidateTag(ctx context.Context, tag string) (err error) {
+	err = _this.process(ctx, "invalidate_tag", nil, func(ctx context.Context) error {
+		return _this.invalidateTag(ctx, tag)
+	})
+
+	return
+}
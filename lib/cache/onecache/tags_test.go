@@ -0,0 +1,106 @@
+package onecache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/1infras/go-kit/driver/redis"
+)
+
+func TestInvalidateTagLocal(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetMaxItems(2))
+	assert.Nil(t, err)
+
+	assert.Nil(t, oneCache.SetWithTags(ctx, "user_42_profile", "a", -1, "user:42"))
+	assert.Nil(t, oneCache.SetWithTags(ctx, "user_42_orders", "b", -1, "user:42", "orders"))
+	assert.Nil(t, oneCache.InvalidateTag(ctx, "user:42"))
+
+	_, err = oneCache.Get(ctx, "user_42_profile")
+	assert.Equal(t, Nil, err)
+	_, err = oneCache.Get(ctx, "user_42_orders")
+	assert.Equal(t, Nil, err)
+
+	// Setting a key again replaces its tags
+	assert.Nil(t, oneCache.SetWithTags(ctx, "user_42_orders", "b", -1, "orders"))
+	assert.Nil(t, oneCache.Set(ctx, "user_42_profile", "a", -1))
+	assert.Nil(t, oneCache.InvalidateTag(ctx, "user:42"))
+
+	_, err = oneCache.Get(ctx, "user_42_profile")
+	assert.Nil(t, err)
+
+	// Evicted keys leave the index, user_42_orders is the oldest key
+	assert.Nil(t, oneCache.SetWithTags(ctx, "other", "c", -1, "other"))
+	client := oneCache.(*Client)
+	client.tagLock.Lock()
+	assert.Equal(t, 0, len(client.tags["orders"]))
+	assert.Equal(t, []string{"other"}, client.keyTags["other"])
+	assert.Equal(t, 1, len(client.keyTags))
+	client.tagLock.Unlock()
+}
+
+func testInvalidateTagRemote(t *testing.T, namespace string, option ClientOptionFunc) {
+	ctx := context.Background()
+
+	newCache := func() OneCache {
+		oneCache, err := NewOneCache(
+			SetContext(ctx),
+			SetExpiration(10*time.Second),
+			SetRemoteCacheNamespace(namespace),
+			option,
+			SetInvalidation(true))
+		assert.Nil(t, err)
+		return oneCache
+	}
+
+	writer, reader := newCache(), newCache()
+	assert.Nil(t, writer.Flush(ctx))
+
+	assert.Nil(t, writer.SetWithTags(ctx, "profile", "a", -1, "user:42"))
+	assert.Nil(t, writer.SetWithTags(ctx, "orders", "b", 5, "user:42", "orders"))
+	assert.Nil(t, writer.SetWithTags(ctx, "catalog", "c", -1, "catalog"))
+
+	// A key written by another instance is found through the remote set
+	assert.Nil(t, reader.SetWithTags(ctx, "avatar", "d", -1, "user:42"))
+
+	assert.Eventually(t, func() bool {
+		elements, err := reader.MGet(ctx, "profile", "orders", "catalog", "avatar")
+		return err == nil && len(elements) == 4
+	}, 3*time.Second, 10*time.Millisecond)
+
+	var keys []string
+	iterator := writer.Keys(ctx, "")
+	for iterator.Next(ctx) {
+		keys = append(keys, iterator.Key())
+	}
+	assert.Nil(t, iterator.Err())
+	assert.ElementsMatch(t, []string{"profile", "orders", "catalog", "avatar"}, keys)
+
+	assert.Nil(t, writer.InvalidateTag(ctx, "user:42"))
+
+	for _, c := range []OneCache{writer, reader} {
+		assert.Eventually(t, func() bool {
+			elements, err := c.MGet(ctx, "profile", "orders", "catalog", "avatar")
+			_, kept := elements["catalog"]
+			return err == nil && len(elements) == 1 && kept
+		}, 3*time.Second, 10*time.Millisecond)
+	}
+
+	assert.Nil(t, writer.Close(ctx))
+	assert.Nil(t, reader.Close(ctx))
+}
+
+func TestInvalidateTagFakeStore(t *testing.T) {
+	testInvalidateTagRemote(t, "fake_tags", SetRemoteStore(NewFakeStore()))
+}
+
+func TestInvalidateTagRedis(t *testing.T) {
+	redisCache, err := redis.NewDefaultRedisUniversalClient()
+	assert.Nil(t, err)
+
+	testInvalidateTagRemote(t, "redis_cache_tags", SetRemoteCache(redisCache))
+}
@@ -0,0 +1,75 @@
+package onecache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// negativeValue is the sentinel is cached for keys are known to be missed in the origin, its magic differs from
+// the magics of envelopes and compressed values so they are never taken for each other
+var negativeValue = []byte{0xff, 'o', 'n', 1}
+
+// errNegativeHit is returned internally when a negative entry is found, it is turned into Nil for callers
+var errNegativeHit = errors.New("cache: key is cached as not found")
+
+// SetExpirationJitter adds a random duration in [0, jitter) to every expiration so keys are written together
+// do not expire together, entries without expiration are not affected
+func SetExpirationJitter(jitter time.Duration) ClientOptionFunc {
+	return func(c *Client) error {
+		if jitter <= 0 {
+			return fmt.Errorf("expiration jitter must be greater than 0")
+		}
+		c.expirationJitter = jitter
+		return nil
+	}
+}
+
+// SetNegativeExpiration enables negative caching, keys are missed in the origin are cached for expiration,
+// reading them returns Nil without going to the remote cache or the loader
+func SetNegativeExpiration(expiration time.Duration) ClientOptionFunc {
+	return func(c *Client) error {
+		if expiration <= 0 {
+			return fmt.Errorf("negative expiration must be greater than 0")
+		}
+		c.negativeExpiration = expiration
+		return nil
+	}
+}
+
+// withJitter returns expiration spread by the configured jitter
+func (_this *Client) withJitter(expiration time.Duration) time.Duration {
+	if _this.expirationJitter <= 0 || expiration <= 0 {
+		return expiration
+	}
+	return expiration + time.Duration(rand.Int63n(int64(_this.expirationJitter)))
+}
+
+func isNegative(b []byte) bool {
+	return bytes.Equal(b, negativeValue)
+}
+
+func (_this *Client) setNotFound(ctx context.Context, key string) error {
+	if _this.negativeExpiration <= 0 {
+		return fmt.Errorf("negative caching is not enabled")
+	}
+
+	atomic.AddUint32(&_this.stat.totalWrites, 1)
+	atomic.AddUint32(&_this.stat.totalOperations, 1)
+	_this.setBytes(ctx, key, negativeValue, _this.negativeExpiration)
+
+	return nil
+}
+
+// SetNotFound caches key as missed in the origin for the negative expiration, Get returns Nil for it until it expires
+func (_this *Client) SetNotFound(ctx context.Context, key string) (err error) {
+	err = _this.process(ctx, "set_not_found", key, func(ctx context.Context) error {
+		return _this.setNotFound(ctx, key)
+	})
+
+	return
+}
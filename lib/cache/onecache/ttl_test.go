@@ -0,0 +1,108 @@
+package onecache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/1infras/go-kit/driver/redis"
+)
+
+func TestExpirationJitter(t *testing.T) {
+	c := &Client{expirationJitter: time.Second}
+
+	spread := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		expiration := c.withJitter(10 * time.Second)
+		assert.True(t, expiration >= 10*time.Second && expiration < 11*time.Second, expiration.String())
+		spread[expiration] = true
+	}
+	assert.True(t, len(spread) > 1)
+
+	// Entries without expiration stay without expiration
+	assert.Equal(t, time.Duration(0), c.withJitter(0))
+}
+
+func TestOneCacheNegativeCaching(t *testing.T) {
+	ctx := context.Background()
+
+	redisCache, err := redis.NewDefaultRedisUniversalClient()
+	assert.Nil(t, err)
+
+	err = redisCache.Del(ctx, "redis_cache_negative:missing").Err()
+	assert.Nil(t, err)
+
+	oneCache, err := NewOneCache(
+		SetContext(ctx),
+		SetExpiration(10*time.Second),
+		SetExpirationJitter(time.Second),
+		SetNegativeExpiration(time.Second),
+		SetRemoteCacheNamespace("redis_cache_negative"),
+		SetRemoteCache(redisCache))
+	assert.Nil(t, err)
+
+	loads := 0
+	loader := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return nil, Nil
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = oneCache.GetOrLoad(ctx, "missing", -1, loader)
+		assert.Equal(t, Nil, err)
+	}
+	assert.Equal(t, 1, loads)
+
+	_, err = oneCache.Get(ctx, "missing")
+	assert.Equal(t, Nil, err)
+	assert.False(t, oneCache.Contains(ctx, "missing"))
+
+	elements, err := oneCache.MGet(ctx, "missing")
+	assert.Nil(t, err)
+	assert.Empty(t, elements)
+
+	// Another instance sees the negative entry through redis
+	remote, err := NewOneCache(
+		SetContext(ctx),
+		SetNegativeExpiration(time.Second),
+		SetRemoteCacheNamespace("redis_cache_negative"),
+		SetRemoteCache(redisCache))
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := remote.Get(ctx, "missing")
+		return err == Nil && remote.Stats().NegativeHits > 0
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.False(t, remote.Contains(ctx, "missing"))
+
+	// A value replaces the negative entry
+	err = oneCache.Set(ctx, "missing", "found", -1)
+	assert.Nil(t, err)
+
+	element, err := oneCache.Get(ctx, "missing")
+	assert.Nil(t, err)
+	assert.Equal(t, "found", element.String())
+	assert.True(t, oneCache.Contains(ctx, "missing"))
+
+	// Negative entries expire with their own expiration
+	err = oneCache.SetNotFound(ctx, "missing")
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := oneCache.GetOrLoad(ctx, "missing", -1, loader)
+		return err == Nil && loads == 2
+	}, 3*time.Second, 50*time.Millisecond)
+
+	disabled, err := NewOneCache(SetContext(ctx))
+	assert.Nil(t, err)
+	assert.NotNil(t, disabled.SetNotFound(ctx, "missing"))
+}
+
+func TestNegativeValueMagic(t *testing.T) {
+	// The sentinel is neither an envelope nor a compressed value
+	assert.False(t, bytes.HasPrefix(negativeValue, envelopeMagic))
+	assert.False(t, bytes.HasPrefix(negativeValue, compressionMagic))
+	assert.False(t, isNegative(wrapEnvelope(negativeValue, time.Second, time.Minute)[:len(negativeValue)]))
+}
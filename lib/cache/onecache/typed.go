@@ -0,0 +1,124 @@
+package onecache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// TypedLoader is used to load a value of type V from the origin when a key is missed in every layer
+type TypedLoader[V any] func(ctx context.Context) (V, error)
+
+// Typed is a OneCache with values of type V, values are encoded and decoded with the serializer of the cache
+// and every call goes through the hooks of the cache
+type Typed[V any] interface {
+	Set(ctx context.Context, key string, value V, expiration int) error
+	SetWithTags(ctx context.Context, key string, value V, expiration int, tags ...string) error
+	Get(ctx context.Context, key string) (V, error)
+	GetOrLoad(ctx context.Context, key string, expiration int, loader TypedLoader[V]) (V, error)
+	Contains(ctx context.Context, key string) bool
+	Delete(ctx context.Context, key string)
+	MGet(ctx context.Context, keys ...string) (map[string]V, error)
+	MSet(ctx context.Context, values map[string]V, expiration int) error
+	MDelete(ctx context.Context, keys ...string)
+	// Cache returns the underlying untyped cache
+	Cache() OneCache
+}
+
+type typed[V any] struct {
+	cache OneCache
+}
+
+// NewTyped wraps cache with values of type V
+func NewTyped[V any](cache OneCache) Typed[V] {
+	return &typed[V]{cache: cache}
+}
+
+// decode decodes element into V, pointer types are allocated so serializers decoding into a
+// pointer of a message such as ProtoSerializer are supported
+func decode[V any](element Element) (V, error) {
+	var v V
+	if element == nil {
+		return v, Nil
+	}
+	if t := reflect.TypeOf(&v).Elem(); t.Kind() == reflect.Ptr {
+		v = reflect.New(t.Elem()).Interface().(V)
+		return v, element.Decode(v)
+	}
+	err := element.Decode(&v)
+	return v, err
+}
+
+func (_this *typed[V]) Cache() OneCache {
+	return _this.cache
+}
+
+func (_this *typed[V]) Set(ctx context.Context, key string, value V, expiration int) error {
+	return _this.cache.Set(ctx, key, value, expiration)
+}
+
+func (_this *typed[V]) SetWithTags(ctx context.Context, key string, value V, expiration int, tags ...string) error {
+	return _this.cache.SetWithTags(ctx, key, value, expiration, tags...)
+}
+
+// Get gets the key and decodes its value, Nil is returned when the key is missed
+func (_this *typed[V]) Get(ctx context.Context, key string) (v V, err error) {
+	element, err := _this.cache.Get(ctx, key)
+	if err != nil {
+		return v, err
+	}
+	return decode[V](element)
+}
+
+// GetOrLoad gets the key like OneCache.GetOrLoad, the loaded value is returned after it is written back
+func (_this *typed[V]) GetOrLoad(ctx context.Context, key string, expiration int, loader TypedLoader[V]) (v V, err error) {
+	if loader == nil {
+		return v, fmt.Errorf("loader must not be empty")
+	}
+
+	element, err := _this.cache.GetOrLoad(ctx, key, expiration, func(ctx context.Context) (interface{}, error) {
+		return loader(ctx)
+	})
+	if err != nil {
+		return v, err
+	}
+	return decode[V](element)
+}
+
+func (_this *typed[V]) Contains(ctx context.Context, key string) bool {
+	return _this.cache.Contains(ctx, key)
+}
+
+func (_this *typed[V]) Delete(ctx context.Context, key string) {
+	_this.cache.Delete(ctx, key)
+}
+
+// MGet gets multiple keys like OneCache.MGet, keys are missed in every layer are not present in the result
+func (_this *typed[V]) MGet(ctx context.Context, keys ...string) (map[string]V, error) {
+	elements, err := _this.cache.MGet(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]V, len(elements))
+	for key, element := range elements {
+		v, err := decode[V](element)
+		if err != nil {
+			return nil, fmt.Errorf("decode value of key %s has error: %v", key, err)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+func (_this *typed[V]) MSet(ctx context.Context, values map[string]V, expiration int) error {
+	untyped := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		untyped[key] = value
+	}
+	return _this.cache.MSet(ctx, untyped, expiration)
+}
+
+func (_this *typed[V]) MDelete(ctx context.Context, keys ...string) {
+	_this.cache.MDelete(ctx, keys...)
+}
@@ -0,0 +1,89 @@
+package onecache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type product struct {
+	Name  string
+	Price int
+}
+
+type nameHook struct {
+	names []string
+}
+
+func (_this *nameHook) BeforeProcess(ctx context.Context, name string) context.Context {
+	_this.names = append(_this.names, name)
+	return ctx
+}
+
+func (_this *nameHook) AfterProcess(ctx context.Context, name string) {}
+
+func TestTyped(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx))
+	assert.Nil(t, err)
+	hook := &nameHook{}
+	oneCache.AddHook(hook)
+
+	products := NewTyped[product](oneCache)
+
+	err = products.Set(ctx, "a", product{Name: "a", Price: 10}, -1)
+	assert.Nil(t, err)
+
+	p, err := products.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, product{Name: "a", Price: 10}, p)
+
+	_, err = products.Get(ctx, "missed")
+	assert.Equal(t, Nil, err)
+
+	p, err = products.GetOrLoad(ctx, "b", -1, func(ctx context.Context) (product, error) {
+		return product{Name: "b", Price: 20}, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, product{Name: "b", Price: 20}, p)
+	assert.True(t, products.Contains(ctx, "b"))
+
+	err = products.MSet(ctx, map[string]product{"c": {Name: "c"}, "d": {Name: "d"}}, -1)
+	assert.Nil(t, err)
+	values, err := products.MGet(ctx, "a", "c", "d", "missed")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]product{"a": {Name: "a", Price: 10}, "c": {Name: "c"}, "d": {Name: "d"}}, values)
+
+	products.MDelete(ctx, "c", "d")
+	products.Delete(ctx, "a")
+	assert.False(t, products.Contains(ctx, "a"))
+
+	// The value of another type can not be decoded
+	err = oneCache.Set(ctx, "e", "e", -1)
+	assert.Nil(t, err)
+	_, err = products.Get(ctx, "e")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []string{"set", "get", "get", "get_or_load", "contains", "mset", "mget", "mdelete", "delete", "contains", "set", "get"}, hook.names)
+	assert.Equal(t, oneCache, products.Cache())
+}
+
+func TestTypedPointer(t *testing.T) {
+	ctx := context.Background()
+
+	oneCache, err := NewOneCache(SetContext(ctx), SetSerializer(&ProtoSerializer{}))
+	assert.Nil(t, err)
+
+	messages := NewTyped[*wrapperspb.StringValue](oneCache)
+
+	err = messages.Set(ctx, "a", &wrapperspb.StringValue{Value: "hello"}, -1)
+	assert.Nil(t, err)
+
+	message, err := messages.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.True(t, proto.Equal(&wrapperspb.StringValue{Value: "hello"}, message))
+}
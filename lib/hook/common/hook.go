@@ -1,29 +1,184 @@
 package common
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Attributes of operations set by the libraries
+const (
+	AttributeTopic     = "topic"
+	AttributePartition = "partition"
+	AttributeOffset    = "offset"
+	AttributeNamespace = "namespace"
+)
 
 type HookProcess interface {
 	BeforeProcess(ctx context.Context, name string) context.Context
 	AfterProcess(ctx context.Context, name string)
 }
 
-// Hook
+// Operation describes a call is wrapped by hooks
+type Operation struct {
+	Name string
+	// Key is the key of the cache entry or the message of the operation, it is nil when there is none
+	Key interface{}
+	// Attributes are other details of the operation such as the topic of a message, they may be shared between
+	// operations so hooks must not modify them
+	Attributes map[string]interface{}
+	// Start is when hooks start processing the operation
+	Start time.Time
+	// Err is the error of the operation, it is set before AfterOperation is called
+	Err error
+}
+
+// Attribute returns the attribute of the operation, nil is returned when it is not set
+func (_this *Operation) Attribute(name string) interface{} {
+	return _this.Attributes[name]
+}
+
+// Duration is the time passed since the operation has started
+func (_this *Operation) Duration() time.Duration {
+	return time.Since(_this.Start)
+}
+
+// OperationHook sees the name, the key, the attributes, the start time and the error of every operation
+type OperationHook interface {
+	BeforeOperation(ctx context.Context, op *Operation) context.Context
+	AfterOperation(ctx context.Context, op *Operation)
+}
+
+// AdaptHook adapts a HookProcess to an OperationHook, the hook only sees the name of operations
+func AdaptHook(hook HookProcess) OperationHook {
+	if h, ok := hook.(OperationHook); ok {
+		return h
+	}
+	return &processAdapter{hook: hook}
+}
+
+type processAdapter struct {
+	hook HookProcess
+}
+
+func (_this *processAdapter) BeforeOperation(ctx context.Context, op *Operation) context.Context {
+	return _this.hook.BeforeProcess(ctx, op.Name)
+}
+
+func (_this *processAdapter) AfterOperation(ctx context.Context, op *Operation) {
+	_this.hook.AfterProcess(ctx, op.Name)
+}
+
+// Next calls the rest of the chain of interceptors and then the operation
+type Next func(ctx context.Context) error
+
+// Interceptor wraps an operation like a middleware, it may change the context, call next zero or more times and
+// change the error returned by next. An interceptor which does not call next short-circuits the operation, the call
+// then returns zero results and the error of the interceptor if the call returns an error
+type Interceptor func(ctx context.Context, op *Operation, next Next) error
+
+// PanicError is the error of an operation seen by hooks when the operation panics, the panic is raised again
+// once every hook has seen it
+type PanicError struct {
+	Value interface{}
+}
+
+func (_this *PanicError) Error() string {
+	return fmt.Sprintf("hook: operation panics: %v", _this.Value)
+}
+
+// intercept adapts an OperationHook to an Interceptor, AfterOperation is called even if next panics
+func intercept(hook OperationHook) Interceptor {
+	return func(ctx context.Context, op *Operation, next Next) error {
+		ctx = hook.BeforeOperation(ctx, op)
+
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			// r is nil when the goroutine exits by runtime.Goexit, there is nothing to raise again
+			r := recover()
+			op.Err = &PanicError{Value: r}
+			hook.AfterOperation(ctx, op)
+			if r != nil {
+				panic(r)
+			}
+		}()
+
+		op.Err = next(ctx)
+		completed = true
+
+		hook.AfterOperation(ctx, op)
+		return op.Err
+	}
+}
+
+// Hook is a chain of interceptors wraps operations, interceptors added first are the outermost so BeforeProcess
+// of hooks run in the order they are added and AfterProcess run in the reverse order.
+// Hooks can be added while operations are processed
 type Hook struct {
-	hooks []HookProcess
+	lock sync.Mutex
+	// interceptors holds an immutable []Interceptor, it is replaced when an interceptor is added
+	interceptors atomic.Value
 }
 
+// AddHook adds a hook, a hook implements OperationHook as well is used as an OperationHook
 func (_this *Hook) AddHook(hook HookProcess) {
-	_this.hooks = append(_this.hooks, hook)
+	_this.AddOperationHook(AdaptHook(hook))
+}
+
+func (_this *Hook) AddOperationHook(hook OperationHook) {
+	_this.AddInterceptor(intercept(hook))
+}
+
+// AddInterceptor adds an interceptor inside the interceptors and hooks are added before
+func (_this *Hook) AddInterceptor(interceptor Interceptor) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	current := _this.chain()
+	interceptors := make([]Interceptor, 0, len(current)+1)
+	interceptors = append(interceptors, current...)
+	_this.interceptors.Store(append(interceptors, interceptor))
+}
+
+func (_this *Hook) chain() []Interceptor {
+	interceptors, _ := _this.interceptors.Load().([]Interceptor)
+	return interceptors
 }
 
 func (_this *Hook) Process(ctx context.Context, fn func(), name string) {
-	for _, h := range _this.hooks {
-		ctx = h.BeforeProcess(ctx, name)
+	_ = _this.ProcessOperation(ctx, Operation{Name: name}, func(ctx context.Context) error {
+		fn()
+		return nil
+	})
+}
+
+// ProcessOperation calls fn through the interceptors and returns the error of the outermost one, hooks see the error of fn
+// in AfterOperation
+func (_this *Hook) ProcessOperation(ctx context.Context, op Operation, fn func(ctx context.Context) error) error {
+	interceptors := _this.chain()
+	if len(interceptors) == 0 {
+		return fn(ctx)
 	}
+	return processOperation(ctx, interceptors, op, fn)
+}
 
-	fn()
+// processOperation is apart from ProcessOperation so op is only allocated when there are hooks
+func processOperation(ctx context.Context, interceptors []Interceptor, op Operation, fn func(ctx context.Context) error) error {
+	op.Start = time.Now()
+	return next(ctx, interceptors, &op, fn)
+}
 
-	for _, h := range _this.hooks {
-		h.AfterProcess(ctx, name)
+// next calls the first interceptor with the rest of the chain as its next
+func next(ctx context.Context, interceptors []Interceptor, op *Operation, fn func(ctx context.Context) error) error {
+	if len(interceptors) == 0 {
+		return fn(ctx)
 	}
+	return interceptors[0](ctx, op, func(ctx context.Context) error {
+		return next(ctx, interceptors[1:], op, fn)
+	})
 }
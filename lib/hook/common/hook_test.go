@@ -0,0 +1,185 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+type nameHook struct {
+	names []string
+}
+
+func (_this *nameHook) BeforeProcess(ctx context.Context, name string) context.Context {
+	_this.names = append(_this.names, "before "+name)
+	return ctx
+}
+
+func (_this *nameHook) AfterProcess(ctx context.Context, name string) {
+	_this.names = append(_this.names, "after "+name)
+}
+
+type operationHook struct {
+	nameHook
+	operations []Operation
+}
+
+func (_this *operationHook) BeforeOperation(ctx context.Context, op *Operation) context.Context {
+	return context.WithValue(ctx, ctxKey{}, op.Name)
+}
+
+func (_this *operationHook) AfterOperation(ctx context.Context, op *Operation) {
+	_this.operations = append(_this.operations, *op)
+}
+
+func TestHook(t *testing.T) {
+	h := &Hook{}
+	ctx := context.Background()
+
+	// Without hooks fn is called with ctx
+	err := h.ProcessOperation(ctx, Operation{Name: "get"}, func(c context.Context) error {
+		require.Equal(t, ctx, c)
+		return nil
+	})
+	require.Nil(t, err)
+
+	names := &nameHook{}
+	operations := &operationHook{}
+	h.AddHook(names)
+	// A HookProcess implements OperationHook is not adapted
+	h.AddHook(operations)
+
+	h.Process(ctx, func() {}, "purge")
+	require.Equal(t, []string{"before purge", "after purge"}, names.names)
+
+	failure := errors.New("failure")
+	err = h.ProcessOperation(ctx, Operation{
+		Name:       "produce",
+		Key:        "key",
+		Attributes: map[string]interface{}{AttributeTopic: "topic"},
+	}, func(c context.Context) error {
+		require.Equal(t, "produce", c.Value(ctxKey{}))
+		return failure
+	})
+	require.Equal(t, failure, err)
+
+	require.Equal(t, []string{"before purge", "after purge", "before produce", "after produce"}, names.names)
+	require.Empty(t, operations.nameHook.names)
+	require.Len(t, operations.operations, 2)
+
+	op := operations.operations[1]
+	require.Equal(t, "produce", op.Name)
+	require.Equal(t, "key", op.Key)
+	require.Equal(t, "topic", op.Attribute(AttributeTopic))
+	require.Nil(t, op.Attribute(AttributePartition))
+	require.Equal(t, failure, op.Err)
+	require.False(t, op.Start.IsZero())
+	require.True(t, op.Duration() > 0)
+	require.Nil(t, operations.operations[0].Err)
+}
+
+type orderHook struct {
+	name  string
+	calls *[]string
+}
+
+func (_this *orderHook) BeforeOperation(ctx context.Context, op *Operation) context.Context {
+	*_this.calls = append(*_this.calls, "before "+_this.name)
+	return ctx
+}
+
+func (_this *orderHook) AfterOperation(ctx context.Context, op *Operation) {
+	*_this.calls = append(*_this.calls, fmt.Sprintf("after %s %v", _this.name, op.Err))
+}
+
+func TestHook_Chain(t *testing.T) {
+	ctx := context.Background()
+	h := &Hook{}
+
+	var calls []string
+	h.AddOperationHook(&orderHook{name: "outer", calls: &calls})
+	denied := errors.New("denied")
+	h.AddInterceptor(func(ctx context.Context, op *Operation, next Next) error {
+		if op.Key == "denied" {
+			return denied
+		}
+		// Errors of next can be changed
+		if err := next(ctx); err != nil {
+			return fmt.Errorf("wrapped: %w", err)
+		}
+		return nil
+	})
+	h.AddOperationHook(&orderHook{name: "inner", calls: &calls})
+
+	failure := errors.New("failure")
+	err := h.ProcessOperation(ctx, Operation{Name: "get", Key: "key"}, func(ctx context.Context) error {
+		calls = append(calls, "fn")
+		return failure
+	})
+	require.True(t, errors.Is(err, failure))
+	require.Equal(t, []string{
+		"before outer",
+		"before inner",
+		"fn",
+		"after inner failure",
+		"after outer wrapped: failure",
+	}, calls)
+
+	// An interceptor short-circuits the operation
+	calls = nil
+	err = h.ProcessOperation(ctx, Operation{Name: "get", Key: "denied"}, func(ctx context.Context) error {
+		calls = append(calls, "fn")
+		return nil
+	})
+	require.Equal(t, denied, err)
+	require.Equal(t, []string{"before outer", "after outer denied"}, calls)
+}
+
+func TestHook_Panic(t *testing.T) {
+	h := &Hook{}
+
+	var calls []string
+	h.AddOperationHook(&orderHook{name: "outer", calls: &calls})
+	h.AddOperationHook(&orderHook{name: "inner", calls: &calls})
+
+	require.PanicsWithValue(t, "boom", func() {
+		h.Process(context.Background(), func() {
+			panic("boom")
+		}, "get")
+	})
+	require.Equal(t, []string{
+		"before outer",
+		"before inner",
+		"after inner hook: operation panics: boom",
+		"after outer hook: operation panics: boom",
+	}, calls)
+}
+
+func TestHook_Concurrent(t *testing.T) {
+	h := &Hook{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				h.Process(context.Background(), func() {}, "get")
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		h.AddInterceptor(func(ctx context.Context, op *Operation, next Next) error {
+			return next(ctx)
+		})
+	}
+	wg.Wait()
+
+	require.Len(t, h.chain(), 10)
+}
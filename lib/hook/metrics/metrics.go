@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/1infras/go-kit/lib/hook/common"
+)
+
+const DefaultNamespace = "hook"
+
+// DefaultBuckets of latency histograms in seconds, operations of LRU take microseconds while remote caches
+// and Kafka take milliseconds
+var DefaultBuckets = []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5}
+
+type CollectorOptionFunc func(*Collector) error
+
+// Collector is a prometheus.Collector exports counters and latency histograms of operations labelled by component
+// and operation, it is registered once and shared by the hooks of every component
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	operations *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+// NewCollector returns a Collector, hooks are created by Hook
+func NewCollector(options ...CollectorOptionFunc) (*Collector, error) {
+	c := &Collector{
+		namespace: DefaultNamespace,
+		buckets:   DefaultBuckets,
+	}
+
+	for _, option := range options {
+		if err := option(c); err != nil {
+			return nil, err
+		}
+	}
+
+	labels := []string{"component", "operation"}
+	c.operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: c.namespace,
+		Name:      "operations_total",
+		Help:      "Total number of operations.",
+	}, labels)
+	c.errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: c.namespace,
+		Name:      "operation_errors_total",
+		Help:      "Total number of operations have failed.",
+	}, labels)
+	c.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: c.namespace,
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of operations.",
+		Buckets:   c.buckets,
+	}, labels)
+
+	return c, nil
+}
+
+// SetNamespace sets the prefix of metric names, it defaults to DefaultNamespace
+func SetNamespace(namespace string) CollectorOptionFunc {
+	return func(c *Collector) error {
+		if namespace == "" {
+			return fmt.Errorf("namespace must not be empty")
+		}
+		c.namespace = namespace
+		return nil
+	}
+}
+
+// SetBuckets sets the buckets of latency histograms in seconds, they default to DefaultBuckets
+func SetBuckets(buckets []float64) CollectorOptionFunc {
+	return func(c *Collector) error {
+		if len(buckets) == 0 {
+			return fmt.Errorf("buckets must not be empty")
+		}
+		c.buckets = buckets
+		return nil
+	}
+}
+
+// Describe implements prometheus.Collector
+func (_this *Collector) Describe(ch chan<- *prometheus.Desc) {
+	_this.operations.Describe(ch)
+	_this.errors.Describe(ch)
+	_this.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (_this *Collector) Collect(ch chan<- prometheus.Metric) {
+	_this.operations.Collect(ch)
+	_this.errors.Collect(ch)
+	_this.duration.Collect(ch)
+}
+
+// Hook returns a hook records operations of component such as "lru", "onecache", "producer" or "consumer".
+// It is added with AddHook, errors of operations are only counted by clients pass them to hooks
+func (_this *Collector) Hook(component string) common.HookProcess {
+	return &hook{
+		collector: _this,
+		component: component,
+	}
+}
+
+// Register registers the collector to r. A Collector is registered already under the same names is shared, so hooks
+// of both collectors record to the registered metrics, other collectors registered under the same names are kept
+func (_this *Collector) Register(r prometheus.Registerer) error {
+	err := r.Register(_this)
+	var registered prometheus.AlreadyRegisteredError
+	if !errors.As(err, &registered) {
+		return err
+	}
+
+	if existing, ok := registered.ExistingCollector.(*Collector); ok {
+		_this.operations = existing.operations
+		_this.errors = existing.errors
+		_this.duration = existing.duration
+	}
+	return nil
+}
+
+// NewHook returns a hook records operations of component with a Collector registered to prometheus.DefaultRegisterer
+func NewHook(component string) (common.HookProcess, error) {
+	return NewHookWithRegisterer(component, prometheus.DefaultRegisterer)
+}
+
+// NewHookWithRegisterer returns a hook records operations of component with a Collector registered to r
+func NewHookWithRegisterer(component string, r prometheus.Registerer) (common.HookProcess, error) {
+	if r == nil {
+		return nil, fmt.Errorf("registerer must not be empty")
+	}
+
+	c, err := NewCollector()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Register(r); err != nil {
+		return nil, fmt.Errorf("register metrics has error: %v", err)
+	}
+	return c.Hook(component), nil
+}
+
+type startKey struct{}
+
+// hook is an implementation of common.HookProcess and common.OperationHook, it is used as an OperationHook
+// by AddHook so errors are seen
+type hook struct {
+	collector *Collector
+	component string
+}
+
+func (_this *hook) BeforeProcess(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, startKey{}, time.Now())
+}
+
+func (_this *hook) AfterProcess(ctx context.Context, name string) {
+	start, ok := ctx.Value(startKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	_this.observe(name, time.Since(start), nil)
+}
+
+func (_this *hook) BeforeOperation(ctx context.Context, op *common.Operation) context.Context {
+	return ctx
+}
+
+func (_this *hook) AfterOperation(ctx context.Context, op *common.Operation) {
+	_this.observe(op.Name, op.Duration(), op.Err)
+}
+
+func (_this *hook) observe(name string, duration time.Duration, err error) {
+	_this.collector.operations.WithLabelValues(_this.component, name).Inc()
+	if err != nil {
+		_this.collector.errors.WithLabelValues(_this.component, name).Inc()
+	}
+	_this.collector.duration.WithLabelValues(_this.component, name).Observe(duration.Seconds())
+}
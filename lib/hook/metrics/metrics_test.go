@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/1infras/go-kit/lib/cache/lru"
+	"github.com/1infras/go-kit/lib/hook/common"
+)
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+
+	collector, err := NewCollector(SetNamespace("test"))
+	assert.Nil(t, err)
+	registry := prometheus.NewPedanticRegistry()
+	assert.Nil(t, registry.Register(collector))
+
+	l, err := lru.New(10)
+	assert.Nil(t, err)
+	l.AddHook(collector.Hook("lru"))
+
+	l.Add(ctx, "a", 1, 0)
+	l.Get(ctx, "a")
+	l.Get(ctx, "b")
+
+	h := &common.Hook{}
+	h.AddHook(collector.Hook("producer"))
+	_ = h.ProcessOperation(ctx, common.Operation{Name: "produce"}, func(ctx context.Context) error {
+		return errors.New("failure")
+	})
+
+	// A hook used as a HookProcess only records the name and the duration
+	process := collector.Hook("consumer")
+	process.AfterProcess(process.BeforeProcess(ctx, "consumer.kafka"), "consumer.kafka")
+
+	expected := `
+		# HELP test_operation_errors_total Total number of operations have failed.
+		# TYPE test_operation_errors_total counter
+		test_operation_errors_total{component="producer",operation="produce"} 1
+		# HELP test_operations_total Total number of operations.
+		# TYPE test_operations_total counter
+		test_operations_total{component="consumer",operation="consumer.kafka"} 1
+		test_operations_total{component="lru",operation="add"} 1
+		test_operations_total{component="lru",operation="get"} 2
+		test_operations_total{component="producer",operation="produce"} 1
+	`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_operations_total", "test_operation_errors_total")
+	assert.Nil(t, err)
+
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	for _, family := range families {
+		if family.GetName() != "test_operation_duration_seconds" {
+			continue
+		}
+		assert.Len(t, family.GetMetric(), 4)
+		for _, m := range family.GetMetric() {
+			assert.True(t, m.GetHistogram().GetSampleCount() > 0)
+		}
+	}
+
+	_, err = NewCollector(SetBuckets(nil))
+	assert.NotNil(t, err)
+}
+
+func TestNewHookWithRegisterer(t *testing.T) {
+	ctx := context.Background()
+	registry := prometheus.NewPedanticRegistry()
+
+	// Hooks registered to the same registry share the registered metrics
+	for _, component := range []string{"lru", "onecache"} {
+		hook, err := NewHookWithRegisterer(component, registry)
+		assert.Nil(t, err)
+		hook.AfterProcess(hook.BeforeProcess(ctx, "get"), "get")
+	}
+
+	expected := `
+		# HELP ` + DefaultNamespace + `_operations_total Total number of operations.
+		# TYPE ` + DefaultNamespace + `_operations_total counter
+		` + DefaultNamespace + `_operations_total{component="lru",operation="get"} 1
+		` + DefaultNamespace + `_operations_total{component="onecache",operation="get"} 1
+	`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected), DefaultNamespace+"_operations_total")
+	assert.Nil(t, err)
+
+	// Other errors of registration are returned
+	conflict := prometheus.NewPedanticRegistry()
+	conflict.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: DefaultNamespace,
+		Name:      "operations_total",
+		Help:      "Total number of operations.",
+	}))
+	_, err = NewHookWithRegisterer("lru", conflict)
+	assert.NotNil(t, err)
+
+	_, err = NewHookWithRegisterer("lru", nil)
+	assert.NotNil(t, err)
+}
@@ -35,10 +35,13 @@ const (
 
 type Consume interface {
 	SetConsumeHandler(Handler)
+	SetConsumeMessageHandler(MessageHandler)
 	Run()
 	GetStats() string
 	ResetStats()
 	AddHook(hook common.HookProcess)
+	AddOperationHook(hook common.OperationHook)
+	AddInterceptor(interceptor common.Interceptor)
 }
 
 type ConsumerOptionFunc func(*Consumer) error
@@ -50,7 +53,8 @@ type Consumer struct {
 	initialOffsetMode   InitialOffsetMode
 	balanceStrategyMode BalanceStrategyMode
 
-	consumeHandler Handler
+	consumeHandler MessageHandler
+	decoder        Decoder
 
 	topic            string
 	group            string
@@ -72,11 +76,31 @@ func (_this *Consumer) AddHook(hook common.HookProcess) {
 	_this.hook.AddHook(hook)
 }
 
+// AddOperationHook adds a hook which sees the topic, the partition, the offset, the key, the start time of every
+// consumed message, the error of the operation is ErrNotConsumed when the handler returns Error
+func (_this *Consumer) AddOperationHook(hook common.OperationHook) {
+	_this.hook.AddOperationHook(hook)
+}
+
+// AddInterceptor adds an interceptor wraps the handler of every consumed message, a message is handled as Error
+// when the chain returns an error, so it is not marked
+func (_this *Consumer) AddInterceptor(interceptor common.Interceptor) {
+	_this.hook.AddInterceptor(interceptor)
+}
+
 func (_this *Consumer) GetStats() string {
 	return _this.report()
 }
 
+// SetConsumeHandler sets a handler of values of messages
 func (_this *Consumer) SetConsumeHandler(h Handler) {
+	_this.consumeHandler = func(ctx context.Context, message *Message) ConsumeStatus {
+		return h(message.Value)
+	}
+}
+
+// SetConsumeMessageHandler sets a handler of messages with their key, headers and trace
+func (_this *Consumer) SetConsumeMessageHandler(h MessageHandler) {
 	_this.consumeHandler = h
 }
 
@@ -92,6 +116,7 @@ func CreateConsumer(client *kafka.Kafka, options ...ConsumerOptionFunc) (Consume
 		task:                2,
 		stat:                &stat{timeStart: time.Now().Unix()},
 		hook:                &common.Hook{},
+		decoder:             &JSONDecoder{},
 	}
 
 	for _, option := range options {
@@ -183,6 +208,17 @@ func SetGroup(group string) ConsumerOptionFunc {
 	}
 }
 
+// SetDecoder sets the Decoder used by Message.Decode, values are decoded with JSONDecoder by default
+func SetDecoder(decoder Decoder) ConsumerOptionFunc {
+	return func(c *Consumer) error {
+		if decoder == nil {
+			return fmt.Errorf("decoder must not be empty")
+		}
+		c.decoder = decoder
+		return nil
+	}
+}
+
 func SetClose(fn func()) ConsumerOptionFunc {
 	return func(c *Consumer) error {
 		c.closeFunc = fn
@@ -199,6 +235,7 @@ func (_this *Consumer) getConsumeGroupHandler() *consumerGroupHandler {
 		mainStream:       make(chan stream, 1000),
 		ticker:           time.NewTicker(1 * time.Second),
 		hook:             _this.hook,
+		decoder:          _this.decoder,
 		ready:            make(chan bool),
 	}
 }
@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/Shopify/sarama"
+	"go.elastic.co/apm"
 
 	"github.com/1infras/go-kit/lib/hook/common"
+	"github.com/1infras/go-kit/tracing"
 )
 
 type stream []*ConsumerSessionMessage
@@ -23,12 +25,13 @@ type consumerGroupHandler struct {
 	bufferStream stream
 	mainStream   chan stream
 	hook         *common.Hook
+	decoder      Decoder
 
 	ticker *time.Ticker
 	lock   sync.RWMutex
 }
 
-func (_this *consumerGroupHandler) processMessage(stat *stat, fn func(message []byte) ConsumeStatus) {
+func (_this *consumerGroupHandler) processMessage(stat *stat, fn MessageHandler) {
 	for i := 0; i < _this.task; i++ {
 		go func(ctx context.Context) {
 			for {
@@ -37,26 +40,7 @@ func (_this *consumerGroupHandler) processMessage(stat *stat, fn func(message []
 					return
 				case ms := <-_this.mainStream:
 					for _, m := range ms {
-						atomic.AddUint32(&stat.totalOperations, 1)
-						atomic.AddInt64(&stat.totalReceivedBytes, int64(len(m.Message.Value)))
-
-						var status ConsumeStatus
-						_this.hook.Process(context.Background(), func() {
-							status = fn(m.Message.Value)
-						}, "consumer.kafka")
-
-						switch status {
-						case Consumed:
-							atomic.AddUint32(&stat.totalConsumed, 1)
-							m.Session.MarkMessage(m.Message, "")
-						case Dispeard:
-							atomic.AddUint32(&stat.totalDispeared, 1)
-							m.Session.MarkMessage(m.Message, "")
-						case Error:
-							atomic.AddUint32(&stat.totalErrors, 1)
-						case Retry:
-							atomic.AddUint32(&stat.totalRetry, 1)
-						}
+						_this.handle(stat, fn, m)
 					}
 				}
 			}
@@ -64,6 +48,69 @@ func (_this *consumerGroupHandler) processMessage(stat *stat, fn func(message []
 	}
 }
 
+// handle calls fn through the hooks and marks the message unless it fails, the message continues the trace
+// of its producer in a transaction when tracing is enabled
+func (_this *consumerGroupHandler) handle(stat *stat, fn MessageHandler, m *ConsumerSessionMessage) {
+	atomic.AddUint32(&stat.totalOperations, 1)
+	atomic.AddInt64(&stat.totalReceivedBytes, int64(len(m.Message.Value)))
+
+	message := newMessage(m.Message, _this.decoder)
+
+	ctx := context.Background()
+	var tx *apm.Transaction
+	if tracing.Enabled {
+		ctx, tx = tracing.StartKafkaTransaction(ctx, "consume "+message.Topic, message.Headers)
+	}
+
+	var status ConsumeStatus
+	err := _this.hook.ProcessOperation(ctx, operation(m.Message), func(ctx context.Context) error {
+		status = fn(ctx, message)
+		if status == Error {
+			return ErrNotConsumed
+		}
+		return nil
+	})
+	if err != nil {
+		status = Error
+	}
+
+	if tx != nil {
+		tx.Result = status.String()
+		if status == Error {
+			tx.Outcome = "failure"
+		} else {
+			tx.Outcome = "success"
+		}
+		tx.End()
+	}
+
+	switch status {
+	case Consumed:
+		atomic.AddUint32(&stat.totalConsumed, 1)
+		m.Session.MarkMessage(m.Message, "")
+	case Dispeard:
+		atomic.AddUint32(&stat.totalDispeared, 1)
+		m.Session.MarkMessage(m.Message, "")
+	case Error:
+		atomic.AddUint32(&stat.totalErrors, 1)
+	case Retry:
+		atomic.AddUint32(&stat.totalRetry, 1)
+	}
+}
+
+// operation describes the consumption of message to hooks
+func operation(message *sarama.ConsumerMessage) common.Operation {
+	return common.Operation{
+		Name: "consumer.kafka",
+		Key:  string(message.Key),
+		Attributes: map[string]interface{}{
+			common.AttributeTopic:     message.Topic,
+			common.AttributePartition: message.Partition,
+			common.AttributeOffset:    message.Offset,
+		},
+	}
+}
+
 func (_this *consumerGroupHandler) flushBuffer() {
 	_this.lock.Lock()
 	defer _this.lock.Unlock()
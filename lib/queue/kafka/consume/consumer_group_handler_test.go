@@ -0,0 +1,92 @@
+package consume
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"go.elastic.co/apm"
+	"go.elastic.co/apm/apmtest"
+	"go.elastic.co/apm/module/apmhttp"
+
+	"github.com/1infras/go-kit/lib/hook/common"
+	"github.com/1infras/go-kit/tracing"
+)
+
+// testSession records marked messages
+type testSession struct {
+	sarama.ConsumerGroupSession
+	marked []int64
+}
+
+func (_this *testSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	_this.marked = append(_this.marked, msg.Offset)
+}
+
+func TestHandleTrace(t *testing.T) {
+	tracing.Enabled = true
+	tracer := apm.DefaultTracer
+	apm.DefaultTracer = apmtest.DiscardTracer
+	defer func() {
+		tracing.Enabled = false
+		apm.DefaultTracer = tracer
+	}()
+
+	producer := apmtest.DiscardTracer.StartTransaction("request", "request")
+	defer producer.End()
+	traceparent := apmhttp.FormatTraceparentHeader(producer.TraceContext())
+
+	handler := &consumerGroupHandler{hook: &common.Hook{}}
+	session := &testSession{}
+
+	var received []*Message
+	fn := func(ctx context.Context, message *Message) ConsumeStatus {
+		received = append(received, message)
+
+		tx := apm.TransactionFromContext(ctx)
+		assert.NotNil(t, tx)
+		if message.Offset == 1 {
+			// The transaction continues the trace of the producer
+			assert.Equal(t, producer.TraceContext().Trace, tx.TraceContext().Trace)
+			return Consumed
+		}
+		assert.NotEqual(t, producer.TraceContext().Trace, tx.TraceContext().Trace)
+		return Error
+	}
+
+	handler.handle(&stat{}, fn, &ConsumerSessionMessage{Session: session, Message: &sarama.ConsumerMessage{
+		Topic:  "my-topic",
+		Key:    []byte("1"),
+		Value:  []byte("a"),
+		Offset: 1,
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("source"), Value: []byte("test")},
+			{Key: []byte(tracing.KafkaTraceparentHeader), Value: []byte(traceparent)},
+		},
+	}})
+	handler.handle(&stat{}, fn, &ConsumerSessionMessage{Session: session, Message: &sarama.ConsumerMessage{
+		Topic:  "my-topic",
+		Value:  []byte("b"),
+		Offset: 2,
+	}})
+
+	assert.Len(t, received, 2)
+	assert.Equal(t, "test", received[0].Headers["source"])
+	assert.Equal(t, []byte("1"), received[0].Key)
+	assert.Nil(t, received[1].Headers)
+	// A message fails is not marked
+	assert.Equal(t, []int64{1}, session.marked)
+}
+
+func TestSetConsumeHandler(t *testing.T) {
+	c := &Consumer{}
+	c.SetConsumeHandler(func(message []byte) ConsumeStatus {
+		assert.Equal(t, []byte("a"), message)
+		return Retry
+	})
+
+	status := c.consumeHandler(context.Background(), &Message{Value: []byte("a")})
+	assert.Equal(t, Retry, status)
+	assert.Equal(t, "retry", status.String())
+}
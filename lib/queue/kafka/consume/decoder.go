@@ -0,0 +1,143 @@
+package consume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/1infras/go-kit/lib/queue/kafka/registry"
+)
+
+// Decoder decodes values of messages consumed from topic into out
+type Decoder interface {
+	Decode(ctx context.Context, topic string, data []byte, out interface{}) error
+}
+
+// JSONDecoder decodes values with encoding/json, it is the default Decoder
+type JSONDecoder struct{}
+
+func (_this *JSONDecoder) Decode(ctx context.Context, topic string, data []byte, out interface{}) error {
+	return json.Unmarshal(data, out)
+}
+
+// RawDecoder copies values into out of type *[]byte or *string
+type RawDecoder struct{}
+
+func (_this *RawDecoder) Decode(ctx context.Context, topic string, data []byte, out interface{}) error {
+	switch v := out.(type) {
+	case *[]byte:
+		*v = append([]byte(nil), data...)
+	case *string:
+		*v = string(data)
+	default:
+		return fmt.Errorf("out of type %T is neither *[]byte nor *string", out)
+	}
+	return nil
+}
+
+// ProtoDecoder decodes values with protocol buffers, out must implement proto.Message
+type ProtoDecoder struct{}
+
+func (_this *ProtoDecoder) Decode(ctx context.Context, topic string, data []byte, out interface{}) error {
+	m, ok := out.(proto.Message)
+	if !ok {
+		return fmt.Errorf("out of type %T is not a proto.Message", out)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// AvroDecoder decodes values in the Confluent wire format with the schema of their id in the registry.
+// Values are decoded into out of type *interface{} or *map[string]interface{} in the native form of goavro,
+// out of other types such as structs are decoded from the JSON encoding of values following their schema
+// like registry.AvroSchema
+type AvroDecoder struct {
+	registry registry.Registry
+
+	lock    sync.RWMutex
+	schemas map[int]*avroSchema
+}
+
+// avroSchema is a schema of the registry parsed for both forms of values
+type avroSchema struct {
+	codec  *goavro.Codec
+	schema *registry.AvroSchema
+}
+
+// NewAvroDecoder returns an AvroDecoder looks up schemas in r
+func NewAvroDecoder(r registry.Registry) (*AvroDecoder, error) {
+	if r == nil {
+		return nil, fmt.Errorf("schema registry must not be empty")
+	}
+	return &AvroDecoder{
+		registry: r,
+		schemas:  make(map[int]*avroSchema),
+	}, nil
+}
+
+func (_this *AvroDecoder) Decode(ctx context.Context, topic string, data []byte, out interface{}) error {
+	id, payload, err := registry.DecodeWireFormat(data)
+	if err != nil {
+		return err
+	}
+
+	schema, err := _this.schema(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	native, _, err := schema.codec.NativeFromBinary(payload)
+	if err != nil {
+		return fmt.Errorf("decode avro value has error: %v", err)
+	}
+
+	switch v := out.(type) {
+	case *interface{}:
+		*v = native
+	case *map[string]interface{}:
+		m, ok := native.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("avro value of type %T is not a record", native)
+		}
+		*v = m
+	default:
+		b, err := schema.schema.JSONFromNative(native)
+		if err != nil {
+			return fmt.Errorf("convert avro value to json has error: %v", err)
+		}
+		return json.Unmarshal(b, out)
+	}
+	return nil
+}
+
+// schema returns the parsed schema of id
+func (_this *AvroDecoder) schema(ctx context.Context, id int) (*avroSchema, error) {
+	_this.lock.RLock()
+	schema, ok := _this.schemas[id]
+	_this.lock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	text, err := _this.registry.Schema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := goavro.NewCodec(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse avro schema %d has error: %v", id, err)
+	}
+	parsed, err := registry.NewAvroSchema(text)
+	if err != nil {
+		return nil, err
+	}
+
+	schema = &avroSchema{codec: codec, schema: parsed}
+	_this.lock.Lock()
+	_this.schemas[id] = schema
+	_this.lock.Unlock()
+	return schema, nil
+}
@@ -0,0 +1,130 @@
+package consume
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/1infras/go-kit/lib/hook/common"
+	"github.com/1infras/go-kit/lib/queue/kafka/produce"
+	"github.com/1infras/go-kit/lib/queue/kafka/registry"
+)
+
+const (
+	userSchema         = `{"type":"record","name":"User","fields":[{"name":"name","type":"string"},{"name":"age","type":"int"}]}`
+	nullableUserSchema = `{"type":"record","name":"User","fields":[{"name":"name","type":"string"},{"name":"email","type":["null","string"],"default":null}]}`
+)
+
+func TestDecoders(t *testing.T) {
+	ctx := context.Background()
+
+	var s string
+	assert.Nil(t, (&JSONDecoder{}).Decode(ctx, "my-topic", []byte(`"hello"`), &s))
+	assert.Equal(t, "hello", s)
+
+	var b []byte
+	assert.Nil(t, (&RawDecoder{}).Decode(ctx, "my-topic", []byte("hello"), &b))
+	assert.Equal(t, []byte("hello"), b)
+	assert.Nil(t, (&RawDecoder{}).Decode(ctx, "my-topic", []byte("hello"), &s))
+	assert.Equal(t, "hello", s)
+	var i int
+	assert.NotNil(t, (&RawDecoder{}).Decode(ctx, "my-topic", []byte("hello"), &i))
+
+	data, err := proto.Marshal(&wrapperspb.StringValue{Value: "hello"})
+	require.Nil(t, err)
+	actual := &wrapperspb.StringValue{}
+	assert.Nil(t, (&ProtoDecoder{}).Decode(ctx, "my-topic", data, actual))
+	assert.Equal(t, "hello", actual.Value)
+	assert.NotNil(t, (&ProtoDecoder{}).Decode(ctx, "my-topic", data, &s))
+}
+
+func TestAvroDecoder(t *testing.T) {
+	ctx := context.Background()
+	r := registry.NewFakeRegistry()
+
+	encoder, err := produce.NewAvroEncoder(r, userSchema)
+	require.Nil(t, err)
+	data, err := encoder.Encode(ctx, "users", map[string]interface{}{"name": "alice", "age": 30})
+	require.Nil(t, err)
+
+	decoder, err := NewAvroDecoder(r)
+	require.Nil(t, err)
+
+	var native interface{}
+	assert.Nil(t, decoder.Decode(ctx, "users", data, &native))
+	assert.Equal(t, map[string]interface{}{"name": "alice", "age": int32(30)}, native)
+
+	var record map[string]interface{}
+	assert.Nil(t, decoder.Decode(ctx, "users", data, &record))
+	assert.Equal(t, "alice", record["name"])
+
+	var user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	assert.Nil(t, decoder.Decode(ctx, "users", data, &user))
+	assert.Equal(t, "alice", user.Name)
+	assert.Equal(t, 30, user.Age)
+
+	assert.Equal(t, registry.ErrWireFormat, decoder.Decode(ctx, "users", []byte(`{"name":"alice"}`), &native))
+	assert.NotNil(t, decoder.Decode(ctx, "users", registry.EncodeWireFormat(100, nil), &native))
+
+	_, err = NewAvroDecoder(nil)
+	assert.NotNil(t, err)
+}
+
+func TestAvroDecoderNullable(t *testing.T) {
+	ctx := context.Background()
+	r := registry.NewFakeRegistry()
+
+	encoder, err := produce.NewAvroEncoder(r, nullableUserSchema)
+	require.Nil(t, err)
+	decoder, err := NewAvroDecoder(r)
+	require.Nil(t, err)
+
+	type user struct {
+		Name  string  `json:"name"`
+		Email *string `json:"email"`
+	}
+	email := "alice@example.com"
+	for _, expected := range []user{{Name: "alice", Email: &email}, {Name: "bob"}} {
+		data, err := encoder.Encode(ctx, "users", expected)
+		require.Nil(t, err)
+
+		var actual user
+		assert.Nil(t, decoder.Decode(ctx, "users", data, &actual))
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestMessageDecode(t *testing.T) {
+	ctx := context.Background()
+
+	handler := &consumerGroupHandler{hook: &common.Hook{}, decoder: &RawDecoder{}}
+	var value string
+	fn := func(ctx context.Context, message *Message) ConsumeStatus {
+		if err := message.Decode(ctx, &value); err != nil {
+			return Error
+		}
+		return Consumed
+	}
+	handler.handle(&stat{}, fn, &ConsumerSessionMessage{Session: &testSession{}, Message: &sarama.ConsumerMessage{
+		Topic: "my-topic",
+		Value: []byte("hello"),
+	}})
+	assert.Equal(t, "hello", value)
+
+	// Messages not consumed are decoded with JSONDecoder
+	message := &Message{Value: []byte(`"hello"`)}
+	assert.Nil(t, message.Decode(ctx, &value))
+	assert.Equal(t, "hello", value)
+
+	c := &Consumer{}
+	assert.Nil(t, SetDecoder(&RawDecoder{})(c))
+	assert.NotNil(t, SetDecoder(nil)(c))
+}
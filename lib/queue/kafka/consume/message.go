@@ -1,12 +1,67 @@
 package consume
 
-import "github.com/Shopify/sarama"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
 
 type ConsumerSessionMessage struct {
 	Session sarama.ConsumerGroupSession
 	Message *sarama.ConsumerMessage
 }
 
+// Message is a consumed message
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Timestamp time.Time
+
+	decoder Decoder
+}
+
+// Decode decodes the value into out with the Decoder of the consumer, it is decoded with JSONDecoder
+// when the message is not consumed
+func (m *Message) Decode(ctx context.Context, out interface{}) error {
+	decoder := m.decoder
+	if decoder == nil {
+		decoder = &JSONDecoder{}
+	}
+	return decoder.Decode(ctx, m.Topic, m.Value, out)
+}
+
+// MessageHandler handles a consumed message, ctx carries the transaction continues the trace of the producer
+// when tracing is enabled
+type MessageHandler func(ctx context.Context, message *Message) ConsumeStatus
+
+func newMessage(m *sarama.ConsumerMessage, decoder Decoder) *Message {
+	message := &Message{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Key:       m.Key,
+		Value:     m.Value,
+		Timestamp: m.Timestamp,
+		decoder:   decoder,
+	}
+
+	if len(m.Headers) > 0 {
+		message.Headers = make(map[string]string, len(m.Headers))
+		for _, header := range m.Headers {
+			if header != nil {
+				message.Headers[string(header.Key)] = string(header.Value)
+			}
+		}
+	}
+	return message
+}
+
 type ConsumeStatus int
 
 const (
@@ -14,4 +69,22 @@ const (
 	Dispeard
 	Error
 	Retry
-)
\ No newline at end of file
+)
+
+func (_this ConsumeStatus) String() string {
+	switch _this {
+	case Consumed:
+		return "consumed"
+	case Dispeard:
+		return "dispeared"
+	case Error:
+		return "error"
+	case Retry:
+		return "retry"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrNotConsumed is the error of the operation passed to hooks when the handler returns Error
+var ErrNotConsumed = errors.New("consumer: message is not consumed")
@@ -9,20 +9,21 @@ type asyncProducer struct {
 	p     sarama.AsyncProducer
 }
 
-func (_this *asyncProducer) produce(msg *Message) (*Message, error) {
-	if msg.Topic == "" {
-		msg.Topic = _this.topic
-	}
-
-	m, err := msg.ToProducerMessage()
-	if err != nil {
-		return nil, err
-	}
-
+func (_this *asyncProducer) produce(msg *Message, m *sarama.ProducerMessage) (*Message, error) {
 	_this.p.Input() <- m
 	return msg, nil
 }
 
+// produceBatch sends messages in order without waiting, errors are reported by the producer
+func (_this *asyncProducer) produceBatch(messages []*Message, ms []*sarama.ProducerMessage) ([]*Message, error) {
+	for i, msg := range messages {
+		if _, err := _this.produce(msg, ms[i]); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
 func (_this *asyncProducer) close() error {
 	return _this.p.Close()
 }
@@ -0,0 +1,159 @@
+package produce
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/1infras/go-kit/driver/kafka"
+	"github.com/1infras/go-kit/lib/hook/common"
+)
+
+// txnProducer counts committed and aborted transactions of the mock
+type txnProducer struct {
+	*mocks.SyncProducer
+	commits int
+	aborts  int
+}
+
+func (_this *txnProducer) CommitTxn() error {
+	_this.commits++
+	return _this.SyncProducer.CommitTxn()
+}
+
+func (_this *txnProducer) AbortTxn() error {
+	_this.aborts++
+	return _this.SyncProducer.AbortTxn()
+}
+
+func newTestProducer(mode ProducerMode, p sarama.SyncProducer) *Producer {
+	return &Producer{
+		produceMode:  mode,
+		topic:        "my-topic",
+		syncProducer: &syncProducer{topic: "my-topic", p: p},
+		stat:         &stat{},
+		hook:         &common.Hook{},
+		encoder:      &JSONEncoder{},
+	}
+}
+
+func TestProduceBatch(t *testing.T) {
+	ctx := context.Background()
+
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageAndSucceed()
+	mock.ExpectSendMessageAndSucceed()
+	producer := newTestProducer(SyncMode, mock)
+
+	ms, err := producer.ProduceBatch(ctx, []*Message{NewMessage("1", "a"), NewMessage("2", "b")})
+	assert.Nil(t, err)
+	assert.Len(t, ms, 2)
+	assert.Equal(t, "my-topic", ms[0].Topic)
+	assert.Equal(t, "1", ms[0].Key)
+	assert.Equal(t, int64(1), ms[0].Offset)
+	assert.Equal(t, "2", ms[1].Key)
+	assert.Equal(t, int64(2), ms[1].Offset)
+	assert.Equal(t, uint32(2), producer.stat.totalSuccesses)
+
+	_, err = producer.ProduceBatch(ctx, []*Message{nil})
+	assert.NotNil(t, err)
+
+	ms, err = producer.ProduceBatch(ctx, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, ms)
+
+	assert.Nil(t, mock.Close())
+}
+
+func TestProduceTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	config.Producer.Idempotent = true
+	config.Producer.Transaction.ID = "ledger"
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Net.MaxOpenRequests = 1
+
+	mock := &txnProducer{SyncProducer: mocks.NewSyncProducer(t, config)}
+	producer := newTestProducer(TransactionalMode, mock)
+
+	mock.ExpectSendMessageAndSucceed()
+	mock.ExpectSendMessageAndSucceed()
+	ms, err := producer.ProduceBatch(ctx, []*Message{NewMessage("1", "a"), NewMessage("2", "b")})
+	assert.Nil(t, err)
+	assert.Len(t, ms, 2)
+	assert.Equal(t, 1, mock.commits)
+
+	mock.ExpectSendMessageAndSucceed()
+	_, err = producer.Produce(ctx, NewMessage("3", "c"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, mock.commits)
+
+	// A failed batch is aborted
+	failure := errors.New("failure")
+	mock.ExpectSendMessageAndSucceed()
+	mock.ExpectSendMessageAndFail(failure)
+	_, err = producer.ProduceBatch(ctx, []*Message{NewMessage("4", "d"), NewMessage("5", "e")})
+	assert.Equal(t, failure, err)
+	assert.Equal(t, 2, mock.commits)
+	assert.Equal(t, 1, mock.aborts)
+	assert.Equal(t, sarama.ProducerTxnFlagReady, mock.TxnStatus())
+
+	assert.Nil(t, mock.Close())
+}
+
+func TestCreateTransactionalProducer(t *testing.T) {
+	_, err := CreateProducer(&kafka.Kafka{Version: sarama.V2_6_0_0},
+		SetTopic("my-topic"),
+		SetProduceMode(TransactionalMode))
+	assert.NotNil(t, err)
+
+	_, err = CreateProducer(&kafka.Kafka{Version: sarama.V0_10_2_0},
+		SetTopic("my-topic"),
+		SetProduceMode(TransactionalMode),
+		SetTransactionalID("ledger"))
+	assert.NotNil(t, err)
+
+	_, err = CreateProducer(&kafka.Kafka{Version: sarama.V2_6_0_0}, SetTransactionalID(""))
+	assert.NotNil(t, err)
+}
+
+func TestProduceBatchTopic(t *testing.T) {
+	ctx := context.Background()
+
+	mock := mocks.NewSyncProducer(t, nil)
+	producer := newTestProducer(SyncMode, mock)
+
+	var topics []interface{}
+	producer.AddInterceptor(func(ctx context.Context, op *common.Operation, next common.Next) error {
+		topics = append(topics, op.Attribute(common.AttributeTopic))
+		return next(ctx)
+	})
+
+	other := NewMessage("2", "b")
+	other.Topic = "other-topic"
+	mock.ExpectSendMessageAndSucceed()
+	mock.ExpectSendMessageAndSucceed()
+	_, err := producer.ProduceBatch(ctx, []*Message{NewMessage("1", "a"), other})
+	assert.Nil(t, err)
+
+	// Messages without a topic go to the topic of the producer
+	same := NewMessage("4", "d")
+	same.Topic = "my-topic"
+	mock.ExpectSendMessageAndSucceed()
+	mock.ExpectSendMessageAndSucceed()
+	_, err = producer.ProduceBatch(ctx, []*Message{NewMessage("3", "c"), same})
+	assert.Nil(t, err)
+
+	mock.ExpectSendMessageAndSucceed()
+	_, err = producer.ProduceBatch(ctx, []*Message{other})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []interface{}{nil, "my-topic", "other-topic"}, topics)
+	assert.Nil(t, mock.Close())
+}
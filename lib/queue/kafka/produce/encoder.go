@@ -0,0 +1,133 @@
+package produce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/1infras/go-kit/lib/queue/kafka/registry"
+)
+
+// Encoder encodes values of messages produced to topic
+type Encoder interface {
+	Encode(ctx context.Context, topic string, value interface{}) ([]byte, error)
+}
+
+// JSONEncoder encodes values with encoding/json, it is the default Encoder
+type JSONEncoder struct{}
+
+func (_this *JSONEncoder) Encode(ctx context.Context, topic string, value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// RawEncoder sends values of type []byte or string as they are
+type RawEncoder struct{}
+
+func (_this *RawEncoder) Encode(ctx context.Context, topic string, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("value of type %T is neither []byte nor string", value)
+	}
+}
+
+// ProtoEncoder encodes values with protocol buffers, values must implement proto.Message
+type ProtoEncoder struct{}
+
+func (_this *ProtoEncoder) Encode(ctx context.Context, topic string, value interface{}) ([]byte, error) {
+	m, ok := value.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("value of type %T is not a proto.Message", value)
+	}
+	return proto.Marshal(m)
+}
+
+// AvroEncoder encodes values with an Avro schema in the Confluent wire format. The schema is registered
+// under the subject "<topic>-value" on first use of a topic.
+// Values of type map[string]interface{}, []interface{} and primitives are in the native form of goavro,
+// values of other types such as structs are converted from their JSON encoding following the schema
+// like registry.AvroSchema
+type AvroEncoder struct {
+	registry registry.Registry
+	codec    *goavro.Codec
+	schema   *registry.AvroSchema
+
+	lock sync.RWMutex
+	ids  map[string]int
+}
+
+// NewAvroEncoder returns an AvroEncoder of schema, it returns an error when schema is invalid
+func NewAvroEncoder(r registry.Registry, schema string) (*AvroEncoder, error) {
+	if r == nil {
+		return nil, fmt.Errorf("schema registry must not be empty")
+	}
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("parse avro schema has error: %v", err)
+	}
+	avroSchema, err := registry.NewAvroSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &AvroEncoder{
+		registry: r,
+		codec:    codec,
+		schema:   avroSchema,
+		ids:      make(map[string]int),
+	}, nil
+}
+
+func (_this *AvroEncoder) Encode(ctx context.Context, topic string, value interface{}) ([]byte, error) {
+	id, err := _this.schemaID(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	native := value
+	switch value.(type) {
+	case nil, bool, int, int32, int64, float32, float64, string, []byte, map[string]interface{}, []interface{}:
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if native, err = _this.schema.NativeFromJSON(b); err != nil {
+			return nil, fmt.Errorf("convert value of type %T to avro has error: %v", value, err)
+		}
+	}
+
+	payload, err := _this.codec.BinaryFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("encode avro value has error: %v", err)
+	}
+	return registry.EncodeWireFormat(id, payload), nil
+}
+
+// schemaID returns the id of the schema registered under the subject of topic
+func (_this *AvroEncoder) schemaID(ctx context.Context, topic string) (int, error) {
+	_this.lock.RLock()
+	id, ok := _this.ids[topic]
+	_this.lock.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	id, err := _this.registry.Register(ctx, topic+"-value", _this.codec.Schema())
+	if err != nil {
+		return 0, err
+	}
+
+	_this.lock.Lock()
+	_this.ids[topic] = id
+	_this.lock.Unlock()
+	return id, nil
+}
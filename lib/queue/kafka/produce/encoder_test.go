@@ -0,0 +1,135 @@
+package produce
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama/mocks"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/1infras/go-kit/lib/queue/kafka/registry"
+)
+
+const (
+	userSchema         = `{"type":"record","name":"User","fields":[{"name":"name","type":"string"},{"name":"age","type":"int"}]}`
+	nullableUserSchema = `{"type":"record","name":"User","fields":[{"name":"name","type":"string"},{"name":"email","type":["null","string"],"default":null}]}`
+)
+
+func TestEncoders(t *testing.T) {
+	ctx := context.Background()
+
+	b, err := (&JSONEncoder{}).Encode(ctx, "my-topic", []byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, `"aGVsbG8="`, string(b))
+
+	b, err = (&RawEncoder{}).Encode(ctx, "my-topic", []byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(b))
+	b, err = (&RawEncoder{}).Encode(ctx, "my-topic", "hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(b))
+	_, err = (&RawEncoder{}).Encode(ctx, "my-topic", 1)
+	assert.NotNil(t, err)
+
+	b, err = (&ProtoEncoder{}).Encode(ctx, "my-topic", &wrapperspb.StringValue{Value: "hello"})
+	assert.Nil(t, err)
+	actual := &wrapperspb.StringValue{}
+	assert.Nil(t, proto.Unmarshal(b, actual))
+	assert.Equal(t, "hello", actual.Value)
+	_, err = (&ProtoEncoder{}).Encode(ctx, "my-topic", "hello")
+	assert.NotNil(t, err)
+}
+
+func TestAvroEncoder(t *testing.T) {
+	ctx := context.Background()
+	r := registry.NewFakeRegistry()
+
+	_, err := NewAvroEncoder(r, `{"type":"record"}`)
+	assert.NotNil(t, err)
+
+	encoder, err := NewAvroEncoder(r, userSchema)
+	require.Nil(t, err)
+
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	for _, value := range []interface{}{
+		map[string]interface{}{"name": "alice", "age": 30},
+		user{Name: "alice", Age: 30},
+	} {
+		b, err := encoder.Encode(ctx, "users", value)
+		require.Nil(t, err)
+
+		id, payload, err := registry.DecodeWireFormat(b)
+		require.Nil(t, err)
+		assert.Equal(t, []int{id}, r.Subjects("users-value"))
+
+		codec, err := goavro.NewCodec(userSchema)
+		require.Nil(t, err)
+		native, _, err := codec.NativeFromBinary(payload)
+		require.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{"name": "alice", "age": int32(30)}, native)
+	}
+
+	_, err = encoder.Encode(ctx, "users", map[string]interface{}{"name": "alice"})
+	assert.NotNil(t, err)
+}
+
+func TestAvroEncoderNullable(t *testing.T) {
+	ctx := context.Background()
+
+	encoder, err := NewAvroEncoder(registry.NewFakeRegistry(), nullableUserSchema)
+	require.Nil(t, err)
+	codec, err := goavro.NewCodec(nullableUserSchema)
+	require.Nil(t, err)
+
+	type user struct {
+		Name  string  `json:"name"`
+		Email *string `json:"email"`
+	}
+	email := "alice@example.com"
+	for _, tc := range []struct {
+		value  user
+		native map[string]interface{}
+	}{
+		{user{Name: "alice", Email: &email}, map[string]interface{}{"name": "alice", "email": goavro.Union("string", email)}},
+		{user{Name: "bob"}, map[string]interface{}{"name": "bob", "email": nil}},
+	} {
+		b, err := encoder.Encode(ctx, "users", tc.value)
+		require.Nil(t, err)
+
+		_, payload, err := registry.DecodeWireFormat(b)
+		require.Nil(t, err)
+		native, _, err := codec.NativeFromBinary(payload)
+		require.Nil(t, err)
+		assert.Equal(t, tc.native, native)
+	}
+}
+
+func TestProduceEncoder(t *testing.T) {
+	ctx := context.Background()
+
+	mock := mocks.NewSyncProducer(t, nil)
+	producer := newTestProducer(SyncMode, mock)
+	assert.Nil(t, SetEncoder(&RawEncoder{})(producer))
+	assert.NotNil(t, SetEncoder(nil)(producer))
+
+	mock.ExpectSendMessageWithCheckerFunctionAndSucceed(func(val []byte) error {
+		assert.Equal(t, "hello", string(val))
+		return nil
+	})
+	m, err := producer.Produce(ctx, NewMessage("1", []byte("hello")))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), m.Value)
+	assert.Equal(t, int64(5), producer.stat.totalReceivedBytes)
+
+	_, err = producer.Produce(ctx, NewMessage("2", 1))
+	assert.NotNil(t, err)
+
+	assert.Nil(t, mock.Close())
+}
@@ -1,11 +1,14 @@
 package produce
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/Shopify/sarama"
+
+	"github.com/1infras/go-kit/tracing"
 )
 
 type Message struct {
@@ -15,6 +18,8 @@ type Message struct {
 	Partition int32       `json:"partition"`
 	Offset    int64       `json:"offset"`
 	Timestamp time.Time   `json:"timestamp"`
+	// Headers are sent with the message, a trace context is added when the producer is traced
+	Headers map[string]string `json:"headers,omitempty"`
 }
 
 func NewMessage(key string, value interface{}) *Message {
@@ -32,8 +37,13 @@ func NewSimpleMessage(value interface{}) *Message {
 	}
 }
 
+// ToProducerMessage encodes the value with encoding/json, producers encode values with their Encoder
 func (m *Message) ToProducerMessage() (*sarama.ProducerMessage, error) {
-	b, err := json.Marshal(m.Value)
+	return m.toProducerMessage(context.Background(), &JSONEncoder{})
+}
+
+func (m *Message) toProducerMessage(ctx context.Context, encoder Encoder) (*sarama.ProducerMessage, error) {
+	b, err := encoder.Encode(ctx, m.Topic, m.Value)
 	if err != nil {
 		return nil, fmt.Errorf("marshall produce message has error: %v", err.Error())
 	}
@@ -42,8 +52,48 @@ func (m *Message) ToProducerMessage() (*sarama.ProducerMessage, error) {
 		Topic:     m.Topic,
 		Key:       sarama.StringEncoder(m.Key),
 		Value:     sarama.ByteEncoder(b),
+		Headers:   m.recordHeaders(),
 		Timestamp: m.Timestamp,
 		Partition: m.Partition,
 		Offset:    m.Offset,
 	}, nil
 }
+
+// recordHeaders converts headers sorted by key, so messages with the same headers are encoded alike
+func (m *Message) recordHeaders() []sarama.RecordHeader {
+	if len(m.Headers) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(m.Headers))
+	for key := range m.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	headers := make([]sarama.RecordHeader, len(keys))
+	for i, key := range keys {
+		headers[i] = sarama.RecordHeader{Key: []byte(key), Value: []byte(m.Headers[key])}
+	}
+	return headers
+}
+
+// withTraceHeaders returns a copy of the message carries the trace context of ctx, headers set by the caller are kept
+func (m *Message) withTraceHeaders(ctx context.Context) *Message {
+	trace := tracing.KafkaTraceHeaders(ctx)
+	if len(trace) == 0 {
+		return m
+	}
+
+	headers := make(map[string]string, len(m.Headers)+len(trace))
+	for key, value := range trace {
+		headers[key] = value
+	}
+	for key, value := range m.Headers {
+		headers[key] = value
+	}
+
+	traced := *m
+	traced.Headers = headers
+	return &traced
+}
@@ -0,0 +1,73 @@
+package produce
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.elastic.co/apm"
+	"go.elastic.co/apm/apmtest"
+	"go.elastic.co/apm/module/apmhttp"
+
+	"github.com/1infras/go-kit/tracing"
+)
+
+func TestMessageHeaders(t *testing.T) {
+	message := NewMessage("1", "a")
+	message.Headers = map[string]string{"b": "2", "a": "1"}
+
+	m, err := message.ToProducerMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("a"), Value: []byte("1")},
+		{Key: []byte("b"), Value: []byte("2")},
+	}, m.Headers)
+
+	m, err = NewMessage("1", "a").ToProducerMessage()
+	assert.Nil(t, err)
+	assert.Empty(t, m.Headers)
+}
+
+func TestProduceTraceHeaders(t *testing.T) {
+	tracing.Enabled = true
+	defer func() {
+		tracing.Enabled = false
+	}()
+
+	tx := apmtest.DiscardTracer.StartTransaction("request", "request")
+	defer tx.End()
+	ctx := apm.ContextWithTransaction(context.Background(), tx)
+	traceparent := apmhttp.FormatTraceparentHeader(tx.TraceContext())
+	tracestate := tx.TraceContext().State.String()
+
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+		assert.Equal(t, []sarama.RecordHeader{
+			{Key: []byte("source"), Value: []byte("test")},
+			{Key: []byte(tracing.KafkaTraceparentHeader), Value: []byte(traceparent)},
+			{Key: []byte(tracing.KafkaTracestateHeader), Value: []byte(tracestate)},
+		}, m.Headers)
+		return nil
+	})
+	producer := newTestProducer(SyncMode, mock)
+
+	message := NewMessage("1", "a")
+	message.Headers = map[string]string{"source": "test"}
+	m, err := producer.Produce(ctx, message)
+	assert.Nil(t, err)
+	assert.Equal(t, traceparent, m.Headers[tracing.KafkaTraceparentHeader])
+	// The message of the caller is not changed
+	assert.Len(t, message.Headers, 1)
+
+	// A trace context set by the caller is kept
+	message.Headers[tracing.KafkaTraceparentHeader] = "custom"
+	assert.Equal(t, "custom", message.withTraceHeaders(ctx).Headers[tracing.KafkaTraceparentHeader])
+
+	// Messages are not changed without a trace
+	message = NewMessage("1", "a")
+	assert.Equal(t, message, message.withTraceHeaders(context.Background()))
+
+	assert.Nil(t, mock.Close())
+}
@@ -2,7 +2,6 @@ package produce
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sync/atomic"
 	"time"
@@ -13,6 +12,7 @@ import (
 	"github.com/1infras/go-kit/driver/kafka"
 	"github.com/1infras/go-kit/lib/hook/common"
 	"github.com/1infras/go-kit/logger"
+	"github.com/1infras/go-kit/tracing"
 	"github.com/1infras/go-kit/util"
 )
 
@@ -22,6 +22,9 @@ type PartitionerMode int
 const (
 	SyncMode ProducerMode = iota
 	AsyncMode
+	// TransactionalMode produces like SyncMode in transactions of an idempotent producer, a batch is committed
+	// or aborted as a whole. It requires a transactional id and Kafka 0.11 or later
+	TransactionalMode
 )
 
 const (
@@ -32,8 +35,11 @@ const (
 
 type Produce interface {
 	Produce(ctx context.Context, message *Message) (*Message, error)
+	ProduceBatch(ctx context.Context, messages []*Message) ([]*Message, error)
 	Close() error
 	AddHook(hook common.HookProcess)
+	AddOperationHook(hook common.OperationHook)
+	AddInterceptor(interceptor common.Interceptor)
 	GetStats() string
 	ResetStats()
 }
@@ -49,8 +55,10 @@ type Producer struct {
 	clientID        string
 	partitionerMode PartitionerMode
 	requireAsks     bool
+	transactionalID string
 
-	topic string
+	topic   string
+	encoder Encoder
 
 	syncProducer  *syncProducer
 	asyncProducer *asyncProducer
@@ -74,14 +82,77 @@ func (_this *Producer) AddHook(hook common.HookProcess) {
 	_this.hook.AddHook(hook)
 }
 
+// AddOperationHook adds a hook which sees the topic, the key, the start time and the error of every produced message
+func (_this *Producer) AddOperationHook(hook common.OperationHook) {
+	_this.hook.AddOperationHook(hook)
+}
+
+// AddInterceptor adds an interceptor wraps every produced message, a message is not produced when the interceptor
+// does not call next
+func (_this *Producer) AddInterceptor(interceptor common.Interceptor) {
+	_this.hook.AddInterceptor(interceptor)
+}
+
 func (_this *Producer) Produce(ctx context.Context, message *Message) (m *Message, err error) {
-	_this.hook.Process(ctx, func() {
-		m, err = _this.produce(message)
-	}, "produce")
+	op := common.Operation{Name: "produce"}
+	if message != nil {
+		topic := message.Topic
+		if topic == "" {
+			topic = _this.topic
+		}
+		op.Key = message.Key
+		op.Attributes = map[string]interface{}{common.AttributeTopic: topic}
+	}
+
+	err = _this.hook.ProcessOperation(ctx, op, func(ctx context.Context) (err error) {
+		m, err = _this.produce(ctx, message)
+		return
+	})
+
+	return
+}
+
+// ProduceBatch produces messages at once, partitions and offsets of produced messages are returned in order.
+// In SyncMode some messages may have been produced when an error is returned, in TransactionalMode the batch
+// is one transaction so either every message or none is visible to consumers read committed messages.
+// In AsyncMode messages are returned as they are queued.
+// The topic of the operation is only set when every message goes to the same topic
+func (_this *Producer) ProduceBatch(ctx context.Context, messages []*Message) (ms []*Message, err error) {
+	op := common.Operation{Name: "produce_batch"}
+	if topic, ok := _this.batchTopic(messages); ok {
+		op.Attributes = map[string]interface{}{common.AttributeTopic: topic}
+	}
+
+	err = _this.hook.ProcessOperation(ctx, op, func(ctx context.Context) (err error) {
+		ms, err = _this.produceBatch(ctx, messages)
+		return
+	})
 
 	return
 }
 
+// batchTopic returns the topic every message goes to, ok is false when messages go to several topics
+func (_this *Producer) batchTopic(messages []*Message) (topic string, ok bool) {
+	seen := false
+	for _, message := range messages {
+		if message == nil {
+			continue
+		}
+		t := message.Topic
+		if t == "" {
+			t = _this.topic
+		}
+		if seen && t != topic {
+			return "", false
+		}
+		topic, seen = t, true
+	}
+	if !seen {
+		topic = _this.topic
+	}
+	return topic, true
+}
+
 func (_this *Producer) GetStats() string {
 	return _this.report()
 }
@@ -99,7 +170,8 @@ func CreateProducer(client *kafka.Kafka, options ...ProducerOptionFunc) (Produce
 		stat: &stat{
 			timeStart: time.Now().Unix(),
 		},
-		hook: &common.Hook{},
+		hook:    &common.Hook{},
+		encoder: &JSONEncoder{},
 	}
 
 	for _, option := range options {
@@ -112,6 +184,15 @@ func CreateProducer(client *kafka.Kafka, options ...ProducerOptionFunc) (Produce
 		return nil, fmt.Errorf("kafka topic must be defined")
 	}
 
+	if p.produceMode == TransactionalMode {
+		if p.transactionalID == "" {
+			return nil, fmt.Errorf("transactional id must be defined in transactional mode")
+		}
+		if !client.Version.IsAtLeast(sarama.V0_11_0_0) {
+			return nil, fmt.Errorf("transactional mode requires kafka version 0.11.0.0 or later, got %v", client.Version)
+		}
+	}
+
 	cfg := sarama.NewConfig()
 	cfg.Version = client.Version
 	cfg.Producer.Return.Successes = true
@@ -144,7 +225,16 @@ func CreateProducer(client *kafka.Kafka, options ...ProducerOptionFunc) (Produce
 		cfg.Producer.RequiredAcks = sarama.WaitForAll
 	}
 
-	if p.produceMode == SyncMode {
+	if p.produceMode == TransactionalMode {
+		// Transactions require an idempotent producer, which waits for every replica and keeps one request
+		// in flight per broker so retries do not reorder messages
+		cfg.Producer.Idempotent = true
+		cfg.Producer.Transaction.ID = p.transactionalID
+		cfg.Producer.RequiredAcks = sarama.WaitForAll
+		cfg.Net.MaxOpenRequests = 1
+	}
+
+	if p.produceMode == SyncMode || p.produceMode == TransactionalMode {
 		sp, err := sarama.NewSyncProducer(client.Brokers, cfg)
 		if err != nil {
 			return nil, fmt.Errorf("create sync producer has error: %v", err)
@@ -176,6 +266,18 @@ func SetProduceMode(mode ProducerMode) ProducerOptionFunc {
 	}
 }
 
+// SetTransactionalID sets the transactional id of the producer in TransactionalMode, it must be unique per producer
+// instance and stable across restarts so transactions of a previous instance are fenced
+func SetTransactionalID(id string) ProducerOptionFunc {
+	return func(p *Producer) error {
+		if id == "" {
+			return fmt.Errorf("transactional id must be defined")
+		}
+		p.transactionalID = id
+		return nil
+	}
+}
+
 func SetContext(ctx context.Context) ProducerOptionFunc {
 	return func(p *Producer) error {
 		if ctx == nil {
@@ -188,6 +290,17 @@ func SetContext(ctx context.Context) ProducerOptionFunc {
 	}
 }
 
+// SetEncoder sets the Encoder of message values, values are encoded with JSONEncoder by default
+func SetEncoder(encoder Encoder) ProducerOptionFunc {
+	return func(p *Producer) error {
+		if encoder == nil {
+			return fmt.Errorf("encoder must not be empty")
+		}
+		p.encoder = encoder
+		return nil
+	}
+}
+
 func SetPartitionerMode(mode PartitionerMode) ProducerOptionFunc {
 	return func(p *Producer) error {
 		p.partitionerMode = mode
@@ -232,37 +345,87 @@ func SetClientID(clientID string) ProducerOptionFunc {
 	}
 }
 
-func (_this *Producer) produce(message *Message) (*Message, error) {
+// encode sets the default topic of message and encodes it with the Encoder of the producer
+func (_this *Producer) encode(ctx context.Context, message *Message) (*sarama.ProducerMessage, error) {
+	if message.Topic == "" {
+		message.Topic = _this.topic
+	}
+	return message.toProducerMessage(ctx, _this.encoder)
+}
+
+func (_this *Producer) produce(ctx context.Context, message *Message) (*Message, error) {
 	if message == nil {
 		return nil, fmt.Errorf("message must not be empty")
 	}
+	if tracing.Enabled {
+		message = message.withTraceHeaders(ctx)
+	}
+
+	pm, err := _this.encode(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddUint32(&_this.stat.totalOperations, 1)
+	atomic.AddInt64(&_this.stat.totalReceivedBytes, int64(pm.Value.Length()))
+
+	if _this.produceMode == AsyncMode {
+		return _this.asyncProducer.produce(message, pm)
+	}
+
+	m, err := _this.syncProducer.produce(message, pm)
+	if err == nil {
+		atomic.AddUint32(&_this.stat.totalSuccesses, 1)
+	} else {
+		atomic.AddUint32(&_this.stat.totalErrors, 1)
+	}
 
-	var (
-		m   *Message
-		err error
-	)
+	return m, err
+}
 
-	defer func() {
-		atomic.AddUint32(&_this.stat.totalOperations, 1)
+func (_this *Producer) produceBatch(ctx context.Context, messages []*Message) ([]*Message, error) {
+	for _, message := range messages {
+		if message == nil {
+			return nil, fmt.Errorf("message must not be empty")
+		}
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	if tracing.Enabled {
+		traced := make([]*Message, len(messages))
+		for i, message := range messages {
+			traced[i] = message.withTraceHeaders(ctx)
+		}
+		messages = traced
+	}
 
-		b, err := json.Marshal(message.Value)
-		if err == nil {
-			atomic.AddInt64(&_this.stat.totalReceivedBytes, int64(len(b)))
+	pms := make([]*sarama.ProducerMessage, len(messages))
+	for i, message := range messages {
+		pm, err := _this.encode(ctx, message)
+		if err != nil {
+			return nil, err
 		}
-	}()
+		pms[i] = pm
+	}
+
+	atomic.AddUint32(&_this.stat.totalOperations, uint32(len(messages)))
+	for _, pm := range pms {
+		atomic.AddInt64(&_this.stat.totalReceivedBytes, int64(pm.Value.Length()))
+	}
 
 	if _this.produceMode == AsyncMode {
-		m, err = _this.asyncProducer.produce(message)
+		return _this.asyncProducer.produceBatch(messages, pms)
+	}
+
+	ms, err := _this.syncProducer.produceBatch(messages, pms)
+	if err == nil {
+		atomic.AddUint32(&_this.stat.totalSuccesses, uint32(len(messages)))
 	} else {
-		m, err = _this.syncProducer.produce(message)
-		if err == nil {
-			atomic.AddUint32(&_this.stat.totalSuccesses, 1)
-		} else {
-			atomic.AddUint32(&_this.stat.totalErrors, 1)
-		}
+		atomic.AddUint32(&_this.stat.totalErrors, uint32(len(messages)))
 	}
 
-	return m, err
+	return ms, err
 }
 
 func (_this *Producer) report() string {
@@ -1,25 +1,29 @@
 package produce
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/Shopify/sarama"
 )
 
 type syncProducer struct {
 	topic string
 	p     sarama.SyncProducer
-}
-
-func (_this *syncProducer) produce(msg *Message) (*Message, error) {
-	if msg.Topic == "" {
-		msg.Topic = _this.topic
-	}
 
-	m, err := msg.ToProducerMessage()
-	if err != nil {
-		return nil, err
-	}
+	// lock serializes transactions, a producer has one transaction at a time
+	lock sync.Mutex
+}
 
-	p, o, err := _this.p.SendMessage(m)
+func (_this *syncProducer) produce(msg *Message, m *sarama.ProducerMessage) (*Message, error) {
+	var (
+		p int32
+		o int64
+	)
+	err := _this.transaction(func() (err error) {
+		p, o, err = _this.p.SendMessage(m)
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -31,9 +35,64 @@ func (_this *syncProducer) produce(msg *Message) (*Message, error) {
 		Partition: p,
 		Offset:    o,
 		Timestamp: msg.Timestamp,
+		Headers:   msg.Headers,
 	}, nil
 }
 
+// produceBatch sends messages at once and waits until every message is acknowledged
+func (_this *syncProducer) produceBatch(messages []*Message, ms []*sarama.ProducerMessage) ([]*Message, error) {
+	err := _this.transaction(func() error {
+		return _this.p.SendMessages(ms)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	produced := make([]*Message, len(messages))
+	for i, msg := range messages {
+		produced[i] = &Message{
+			Topic:     msg.Topic,
+			Key:       msg.Key,
+			Value:     msg.Value,
+			Partition: ms[i].Partition,
+			Offset:    ms[i].Offset,
+			Timestamp: msg.Timestamp,
+			Headers:   msg.Headers,
+		}
+	}
+	return produced, nil
+}
+
+// transaction calls send in a transaction when the producer is transactional, the transaction is aborted
+// when send fails so none of its messages are visible to consumers read committed messages
+func (_this *syncProducer) transaction(send func() error) error {
+	if !_this.p.IsTransactional() {
+		return send()
+	}
+
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	if err := _this.p.BeginTxn(); err != nil {
+		return fmt.Errorf("begin transaction has error: %v", err)
+	}
+
+	if err := send(); err != nil {
+		if abortErr := _this.p.AbortTxn(); abortErr != nil {
+			return fmt.Errorf("abort transaction has error: %v, produce has error: %v", abortErr, err)
+		}
+		return err
+	}
+
+	if err := _this.p.CommitTxn(); err != nil {
+		if _this.p.TxnStatus()&sarama.ProducerTxnFlagAbortableError != 0 {
+			_ = _this.p.AbortTxn()
+		}
+		return fmt.Errorf("commit transaction has error: %v", err)
+	}
+	return nil
+}
+
 func (_this *syncProducer) close() error {
 	return _this.p.Close()
-}
\ No newline at end of file
+}
@@ -0,0 +1,403 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// unionLogicalTypes are the logical types goavro names the branches of unions after
+var unionLogicalTypes = map[string]bool{
+	"int.date":              true,
+	"int.time-millis":       true,
+	"long.time-micros":      true,
+	"long.timestamp-millis": true,
+	"long.timestamp-micros": true,
+}
+
+// AvroSchema converts values between the JSON encoding of Go values and the native form of goavro following a schema,
+// values of unions such as ["null","string"] are plain JSON values while goavro wraps them with the name of their branch.
+// Bytes and fixed are base64 strings like []byte in encoding/json, timestamps and dates are RFC 3339 strings like
+// time.Time, other logical types such as decimal are only supported in the native form of goavro
+type AvroSchema struct {
+	schema interface{}
+	// names holds named types by their full name
+	names map[string]map[string]interface{}
+}
+
+// NewAvroSchema parses schema, it returns an error when schema is not valid JSON
+func NewAvroSchema(schema string) (*AvroSchema, error) {
+	s := &AvroSchema{names: make(map[string]map[string]interface{})}
+	if err := json.Unmarshal([]byte(schema), &s.schema); err != nil {
+		return nil, fmt.Errorf("parse avro schema has error: %v", err)
+	}
+	s.register(s.schema, "")
+	return s, nil
+}
+
+// NativeFromJSON converts the JSON encoding of a value to the native form of goavro
+func (_this *AvroSchema) NativeFromJSON(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	return _this.toNative(_this.schema, "", v)
+}
+
+// JSONFromNative converts a value in the native form of goavro to its JSON encoding
+func (_this *AvroSchema) JSONFromNative(native interface{}) ([]byte, error) {
+	v, err := _this.fromNative(_this.schema, "", native)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// register adds named types defined in schema to names
+func (_this *AvroSchema) register(schema interface{}, namespace string) {
+	switch s := schema.(type) {
+	case []interface{}:
+		for _, branch := range s {
+			_this.register(branch, namespace)
+		}
+	case map[string]interface{}:
+		switch s["type"] {
+		case "record", "error", "enum", "fixed":
+			name, ns := fullName(s, namespace)
+			_this.names[name] = s
+			fields, _ := s["fields"].([]interface{})
+			for _, field := range fields {
+				if f, ok := field.(map[string]interface{}); ok {
+					_this.register(f["type"], ns)
+				}
+			}
+		case "array":
+			_this.register(s["items"], namespace)
+		case "map":
+			_this.register(s["values"], namespace)
+		default:
+			_this.register(s["type"], namespace)
+		}
+	}
+}
+
+// fullName returns the full name of a named type and the namespace of the types it encloses
+func fullName(schema map[string]interface{}, namespace string) (name, ns string) {
+	name, _ = schema["name"].(string)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name, name[:i]
+	}
+	if n, _ := schema["namespace"].(string); n != "" {
+		namespace = n
+	}
+	if namespace == "" {
+		return name, ""
+	}
+	return namespace + "." + name, namespace
+}
+
+// resolve returns the named type referenced by name in namespace
+func (_this *AvroSchema) resolve(name, namespace string) (map[string]interface{}, bool) {
+	if s, ok := _this.names[name]; ok {
+		return s, true
+	}
+	s, ok := _this.names[namespace+"."+name]
+	return s, ok
+}
+
+// branchName returns the name goavro gives to a branch of a union
+func (_this *AvroSchema) branchName(schema interface{}, namespace string) string {
+	switch s := schema.(type) {
+	case string:
+		if named, ok := _this.resolve(s, namespace); ok {
+			name, _ := fullName(named, namespace)
+			return name
+		}
+		return s
+	case map[string]interface{}:
+		switch t := s["type"].(type) {
+		case string:
+			switch t {
+			case "record", "error", "enum", "fixed":
+				name, _ := fullName(s, namespace)
+				return name
+			case "array", "map":
+				return t
+			}
+			if lt, ok := s["logicalType"].(string); ok && unionLogicalTypes[t+"."+lt] {
+				return t + "." + lt
+			}
+		}
+		return _this.branchName(s["type"], namespace)
+	}
+	return ""
+}
+
+func (_this *AvroSchema) toNative(schema interface{}, namespace string, v interface{}) (interface{}, error) {
+	switch s := schema.(type) {
+	case string:
+		if named, ok := _this.resolve(s, namespace); ok {
+			return _this.toNative(named, namespace, v)
+		}
+		return primitiveToNative(s, v)
+	case []interface{}:
+		return _this.unionToNative(s, namespace, v)
+	case map[string]interface{}:
+		return _this.complexToNative(s, namespace, v)
+	default:
+		return nil, fmt.Errorf("invalid avro schema of type %T", schema)
+	}
+}
+
+// unionToNative wraps v with the name of the first branch it matches
+func (_this *AvroSchema) unionToNative(branches []interface{}, namespace string, v interface{}) (interface{}, error) {
+	for _, branch := range branches {
+		native, err := _this.toNative(branch, namespace, v)
+		if err != nil {
+			continue
+		}
+		if native == nil {
+			return nil, nil
+		}
+		return goavro.Union(_this.branchName(branch, namespace), native), nil
+	}
+	return nil, fmt.Errorf("value %v matches no branch of union", v)
+}
+
+func (_this *AvroSchema) complexToNative(s map[string]interface{}, namespace string, v interface{}) (interface{}, error) {
+	switch s["type"] {
+	case "record", "error":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("value of type %T is not a record", v)
+		}
+		_, ns := fullName(s, namespace)
+		record := make(map[string]interface{}, len(m))
+		fields, _ := s["fields"].([]interface{})
+		for _, field := range fields {
+			f, _ := field.(map[string]interface{})
+			name, _ := f["name"].(string)
+			fv, ok := m[name]
+			if !ok {
+				// goavro uses the default of missing fields
+				continue
+			}
+			native, err := _this.toNative(f["type"], ns, fv)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", name, err)
+			}
+			record[name] = native
+		}
+		return record, nil
+	case "enum":
+		symbol, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of type %T is not an enum symbol", v)
+		}
+		symbols, _ := s["symbols"].([]interface{})
+		for _, sym := range symbols {
+			if sym == symbol {
+				return symbol, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a symbol of enum", symbol)
+	case "fixed":
+		b, err := bytesToNative(v)
+		if err != nil {
+			return nil, err
+		}
+		if size, _ := s["size"].(float64); len(b) != int(size) {
+			return nil, fmt.Errorf("value of %d bytes does not match fixed of size %v", len(b), size)
+		}
+		return b, nil
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			if v == nil {
+				return []interface{}{}, nil
+			}
+			return nil, fmt.Errorf("value of type %T is not an array", v)
+		}
+		array := make([]interface{}, len(items))
+		for i, item := range items {
+			native, err := _this.toNative(s["items"], namespace, item)
+			if err != nil {
+				return nil, err
+			}
+			array[i] = native
+		}
+		return array, nil
+	case "map":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			if v == nil {
+				return map[string]interface{}{}, nil
+			}
+			return nil, fmt.Errorf("value of type %T is not a map", v)
+		}
+		values := make(map[string]interface{}, len(m))
+		for key, value := range m {
+			native, err := _this.toNative(s["values"], namespace, value)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = native
+		}
+		return values, nil
+	}
+
+	if t, ok := s["type"].(string); ok {
+		if lt, _ := s["logicalType"].(string); lt != "" && unionLogicalTypes[t+"."+lt] {
+			if str, ok := v.(string); ok {
+				return time.Parse(time.RFC3339Nano, str)
+			}
+		}
+	}
+	return _this.toNative(s["type"], namespace, v)
+}
+
+func primitiveToNative(name string, v interface{}) (interface{}, error) {
+	switch name {
+	case "null":
+		if v != nil {
+			return nil, fmt.Errorf("value of type %T is not null", v)
+		}
+		return nil, nil
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return nil, fmt.Errorf("value of type %T is not a boolean", v)
+		}
+		return v, nil
+	case "int", "long":
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("value of type %T is not a number", v)
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return nil, err
+		}
+		if name == "int" {
+			if int64(int32(i)) != i {
+				return nil, fmt.Errorf("value %d overflows int", i)
+			}
+			return int32(i), nil
+		}
+		return i, nil
+	case "float", "double":
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("value of type %T is not a number", v)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, err
+		}
+		if name == "float" {
+			return float32(f), nil
+		}
+		return f, nil
+	case "string":
+		if _, ok := v.(string); !ok {
+			return nil, fmt.Errorf("value of type %T is not a string", v)
+		}
+		return v, nil
+	case "bytes":
+		return bytesToNative(v)
+	default:
+		return nil, fmt.Errorf("unknown avro type %q", name)
+	}
+}
+
+// bytesToNative decodes a base64 string like encoding/json does for []byte
+func bytesToNative(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		if v == nil {
+			return []byte{}, nil
+		}
+		return nil, fmt.Errorf("value of type %T is not a base64 string", v)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// fromNative unwraps values of unions, other values are left to encoding/json
+func (_this *AvroSchema) fromNative(schema interface{}, namespace string, native interface{}) (interface{}, error) {
+	switch s := schema.(type) {
+	case string:
+		if named, ok := _this.resolve(s, namespace); ok {
+			return _this.fromNative(named, namespace, native)
+		}
+		return native, nil
+	case []interface{}:
+		if native == nil {
+			return nil, nil
+		}
+		wrapped, ok := native.(map[string]interface{})
+		if !ok || len(wrapped) != 1 {
+			return nil, fmt.Errorf("value of type %T is not a union", native)
+		}
+		for name, value := range wrapped {
+			for _, branch := range s {
+				if _this.branchName(branch, namespace) == name {
+					return _this.fromNative(branch, namespace, value)
+				}
+			}
+			return nil, fmt.Errorf("%q is not a branch of union", name)
+		}
+	case map[string]interface{}:
+		switch s["type"] {
+		case "record", "error":
+			m, ok := native.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("value of type %T is not a record", native)
+			}
+			_, ns := fullName(s, namespace)
+			record := make(map[string]interface{}, len(m))
+			fields, _ := s["fields"].([]interface{})
+			for _, field := range fields {
+				f, _ := field.(map[string]interface{})
+				name, _ := f["name"].(string)
+				v, err := _this.fromNative(f["type"], ns, m[name])
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %v", name, err)
+				}
+				record[name] = v
+			}
+			return record, nil
+		case "array":
+			items, _ := native.([]interface{})
+			array := make([]interface{}, len(items))
+			for i, item := range items {
+				v, err := _this.fromNative(s["items"], namespace, item)
+				if err != nil {
+					return nil, err
+				}
+				array[i] = v
+			}
+			return array, nil
+		case "map":
+			m, _ := native.(map[string]interface{})
+			values := make(map[string]interface{}, len(m))
+			for key, value := range m {
+				v, err := _this.fromNative(s["values"], namespace, value)
+				if err != nil {
+					return nil, err
+				}
+				values[key] = v
+			}
+			return values, nil
+		case "enum", "fixed":
+			return native, nil
+		}
+		return _this.fromNative(s["type"], namespace, native)
+	}
+	return native, nil
+}
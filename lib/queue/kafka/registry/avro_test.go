@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderSchema = `{
+	"type": "record", "name": "Order", "namespace": "shop",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "note", "type": ["null", "string"], "default": null},
+		{"name": "amount", "type": ["null", "int", "double"]},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "PAID"]}},
+		{"name": "address", "type": ["null", {"type": "record", "name": "Address", "fields": [{"name": "city", "type": "string"}]}]},
+		{"name": "previous", "type": ["null", "Address"], "default": null},
+		{"name": "items", "type": {"type": "array", "items": ["null", "string"]}},
+		{"name": "attributes", "type": {"type": "map", "values": "bytes"}},
+		{"name": "paid_at", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]}
+	]
+}`
+
+func TestAvroSchema(t *testing.T) {
+	s, err := NewAvroSchema(orderSchema)
+	require.Nil(t, err)
+	codec, err := goavro.NewCodec(orderSchema)
+	require.Nil(t, err)
+
+	paidAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		json   string
+		native map[string]interface{}
+	}{
+		{
+			json: `{"id":1,"note":"fragile","amount":5,"status":"PAID","address":{"city":"Hanoi"},"previous":{"city":"Hue"},` +
+				`"items":["a",null],"attributes":{"k":"dg=="},"paid_at":"2024-01-02T03:04:05Z"}`,
+			native: map[string]interface{}{
+				"id":         int64(1),
+				"note":       goavro.Union("string", "fragile"),
+				"amount":     goavro.Union("int", int32(5)),
+				"status":     "PAID",
+				"address":    goavro.Union("shop.Address", map[string]interface{}{"city": "Hanoi"}),
+				"previous":   goavro.Union("shop.Address", map[string]interface{}{"city": "Hue"}),
+				"items":      []interface{}{goavro.Union("string", "a"), nil},
+				"attributes": map[string]interface{}{"k": []byte("v")},
+				"paid_at":    goavro.Union("long.timestamp-millis", paidAt),
+			},
+		},
+		{
+			json: `{"id":2,"note":null,"amount":1.5,"status":"NEW","address":null,"previous":null,"items":[],"attributes":{},"paid_at":null}`,
+			native: map[string]interface{}{
+				"id":         int64(2),
+				"note":       nil,
+				"amount":     goavro.Union("double", 1.5),
+				"status":     "NEW",
+				"address":    nil,
+				"previous":   nil,
+				"items":      []interface{}{},
+				"attributes": map[string]interface{}{},
+				"paid_at":    nil,
+			},
+		},
+	} {
+		native, err := s.NativeFromJSON([]byte(tc.json))
+		require.Nil(t, err)
+		assert.Equal(t, tc.native, native)
+
+		// The native form is encoded by goavro and converted back to the same JSON
+		b, err := codec.BinaryFromNative(nil, native)
+		require.Nil(t, err)
+		decoded, _, err := codec.NativeFromBinary(b)
+		require.Nil(t, err)
+		actual, err := s.JSONFromNative(decoded)
+		require.Nil(t, err)
+		assert.JSONEq(t, tc.json, string(actual))
+	}
+
+	_, err = s.NativeFromJSON([]byte(`{"id":1,"note":1}`))
+	assert.NotNil(t, err)
+	_, err = s.NativeFromJSON([]byte(`{"id":1,"status":"CANCELLED"}`))
+	assert.NotNil(t, err)
+	_, err = NewAvroSchema(`{`)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeRegistry is an in-process Registry for tests, a schema registered under several subjects keeps one id
+// like in the schema registry
+type FakeRegistry struct {
+	lock     sync.RWMutex
+	ids      map[string]int
+	schemas  map[int]string
+	subjects map[string][]int
+}
+
+// NewFakeRegistry returns an empty FakeRegistry
+func NewFakeRegistry() *FakeRegistry {
+	return &FakeRegistry{
+		ids:      make(map[string]int),
+		schemas:  make(map[int]string),
+		subjects: make(map[string][]int),
+	}
+}
+
+func (_this *FakeRegistry) Register(ctx context.Context, subject, schema string) (int, error) {
+	_this.lock.Lock()
+	defer _this.lock.Unlock()
+
+	id, ok := _this.ids[schema]
+	if !ok {
+		id = len(_this.ids) + 1
+		_this.ids[schema] = id
+		_this.schemas[id] = schema
+	}
+
+	for _, registered := range _this.subjects[subject] {
+		if registered == id {
+			return id, nil
+		}
+	}
+	_this.subjects[subject] = append(_this.subjects[subject], id)
+	return id, nil
+}
+
+func (_this *FakeRegistry) Schema(ctx context.Context, id int) (string, error) {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	schema, ok := _this.schemas[id]
+	if !ok {
+		return "", fmt.Errorf("schema %d is not found", id)
+	}
+	return schema, nil
+}
+
+// Subjects returns the ids of schemas registered under subject in order
+func (_this *FakeRegistry) Subjects(subject string) []int {
+	_this.lock.RLock()
+	defer _this.lock.RUnlock()
+
+	return append([]int(nil), _this.subjects[subject]...)
+}
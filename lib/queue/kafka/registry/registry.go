@@ -0,0 +1,204 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout = 10 * time.Second
+
+	contentType = "application/vnd.schemaregistry.v1+json"
+	// magicByte starts every value in the Confluent wire format
+	magicByte = 0
+)
+
+// ErrWireFormat is returned when data is not in the Confluent wire format
+var ErrWireFormat = errors.New("registry: data is not in the confluent wire format")
+
+// Registry looks up schemas of a Confluent compatible schema registry
+type Registry interface {
+	// Register registers schema under subject unless it is registered already and returns its id
+	Register(ctx context.Context, subject, schema string) (id int, err error)
+	// Schema returns the schema of id
+	Schema(ctx context.Context, id int) (schema string, err error)
+}
+
+// EncodeWireFormat prefixes payload with the magic byte and the id of its schema
+func EncodeWireFormat(id int, payload []byte) []byte {
+	data := make([]byte, 5+len(payload))
+	data[0] = magicByte
+	binary.BigEndian.PutUint32(data[1:5], uint32(id))
+	copy(data[5:], payload)
+	return data
+}
+
+// DecodeWireFormat returns the id of the schema and the payload of data
+func DecodeWireFormat(data []byte) (id int, payload []byte, err error) {
+	if len(data) < 5 || data[0] != magicByte {
+		return 0, nil, ErrWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
+
+type ClientOptionFunc func(*Client) error
+
+// Client is a Registry over the REST API of the schema registry, ids and schemas are cached
+// since they never change once registered
+type Client struct {
+	url        string
+	httpClient *http.Client
+	username   string
+	password   string
+
+	lock    sync.RWMutex
+	ids     map[string]int
+	schemas map[int]string
+}
+
+// NewClient returns a Client of the schema registry at url
+func NewClient(registryURL string, options ...ClientOptionFunc) (Registry, error) {
+	if registryURL == "" {
+		return nil, fmt.Errorf("schema registry url must not be empty")
+	}
+	if _, err := url.Parse(registryURL); err != nil {
+		return nil, fmt.Errorf("parse schema registry url has error: %v", err)
+	}
+
+	c := &Client{
+		url:        strings.TrimSuffix(registryURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		ids:        make(map[string]int),
+		schemas:    make(map[int]string),
+	}
+
+	for _, option := range options {
+		if err := option(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func SetHTTPClient(httpClient *http.Client) ClientOptionFunc {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return fmt.Errorf("http client must not be empty")
+		}
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+func SetBasicAuth(username, password string) ClientOptionFunc {
+	return func(c *Client) error {
+		if username == "" {
+			return fmt.Errorf("username must not be empty")
+		}
+		c.username = username
+		c.password = password
+		return nil
+	}
+}
+
+func (_this *Client) Register(ctx context.Context, subject, schema string) (int, error) {
+	key := subject + "\x00" + schema
+
+	_this.lock.RLock()
+	id, ok := _this.ids[key]
+	_this.lock.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		ID int `json:"id"`
+	}
+	if err := _this.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", body, &response); err != nil {
+		return 0, fmt.Errorf("register schema of subject %s has error: %v", subject, err)
+	}
+
+	_this.lock.Lock()
+	_this.ids[key] = response.ID
+	_this.schemas[response.ID] = schema
+	_this.lock.Unlock()
+
+	return response.ID, nil
+}
+
+func (_this *Client) Schema(ctx context.Context, id int) (string, error) {
+	_this.lock.RLock()
+	schema, ok := _this.schemas[id]
+	_this.lock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var response struct {
+		Schema string `json:"schema"`
+	}
+	if err := _this.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &response); err != nil {
+		return "", fmt.Errorf("get schema %d has error: %v", id, err)
+	}
+
+	_this.lock.Lock()
+	_this.schemas[id] = response.Schema
+	_this.lock.Unlock()
+
+	return response.Schema, nil
+}
+
+// do sends a request to the registry and decodes its response to out
+func (_this *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, _this.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if _this.username != "" {
+		req.SetBasicAuth(_this.username, _this.password)
+	}
+
+	res, err := _this.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			ErrorCode int    `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Message != "" {
+			return fmt.Errorf("status %d, error code %d: %s", res.StatusCode, e.ErrorCode, e.Message)
+		}
+		return fmt.Errorf("status %d", res.StatusCode)
+	}
+
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer returns a stand-in of the schema registry serves the endpoints used by Client
+func newTestServer(t *testing.T) (*httptest.Server, *int) {
+	fake := NewFakeRegistry()
+	var (
+		lock     sync.Mutex
+		requests int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests++
+		lock.Unlock()
+
+		w.Header().Set("Content-Type", contentType)
+		switch {
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/subjects/") && strings.HasSuffix(r.URL.Path, "/versions"):
+			var body struct {
+				Schema string `json:"schema"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Schema == "" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 42201, "message": "Invalid schema"})
+				return
+			}
+			subject := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions")
+			id, _ := fake.Register(r.Context(), subject, body.Schema)
+			_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/schemas/ids/"):
+			id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/schemas/ids/"))
+			schema, err := fake.Schema(r.Context(), id)
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 40403, "message": "Schema not found"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	server, requests := newTestServer(t)
+
+	c, err := NewClient(server.URL + "/")
+	require.Nil(t, err)
+
+	id, err := c.Register(ctx, "orders-value", `"string"`)
+	require.Nil(t, err)
+	assert.Equal(t, 1, id)
+
+	// registered schemas are cached
+	id, err = c.Register(ctx, "orders-value", `"string"`)
+	require.Nil(t, err)
+	assert.Equal(t, 1, id)
+	schema, err := c.Schema(ctx, id)
+	require.Nil(t, err)
+	assert.Equal(t, `"string"`, schema)
+	assert.Equal(t, 1, *requests)
+
+	// schemas registered by other clients are fetched
+	other, err := NewClient(server.URL)
+	require.Nil(t, err)
+	schema, err = other.Schema(ctx, id)
+	require.Nil(t, err)
+	assert.Equal(t, `"string"`, schema)
+	assert.Equal(t, 2, *requests)
+
+	_, err = other.Schema(ctx, 100)
+	assert.Contains(t, err.Error(), "Schema not found")
+	_, err = c.Register(ctx, "orders-value", "")
+	assert.Contains(t, err.Error(), "Invalid schema")
+
+	_, err = NewClient("")
+	assert.NotNil(t, err)
+}
+
+func TestWireFormat(t *testing.T) {
+	data := EncodeWireFormat(258, []byte("payload"))
+	assert.Equal(t, []byte{0, 0, 0, 1, 2}, data[:5])
+
+	id, payload, err := DecodeWireFormat(data)
+	require.Nil(t, err)
+	assert.Equal(t, 258, id)
+	assert.Equal(t, []byte("payload"), payload)
+
+	_, _, err = DecodeWireFormat([]byte{1, 0, 0, 0, 1})
+	assert.Equal(t, ErrWireFormat, err)
+	_, _, err = DecodeWireFormat([]byte{0, 0})
+	assert.Equal(t, ErrWireFormat, err)
+}
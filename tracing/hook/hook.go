@@ -9,6 +9,8 @@ import (
 )
 
 // hook is an implementation of common.HookProcess that reports command name as spans to Elastic APM.
+// It implements common.OperationHook as well, so spans of operations are labeled with their key and attributes
+// and are marked failed with the error captured when the operation fails.
 type hook struct {
 	spanName string
 }
@@ -30,3 +32,35 @@ func (_this *hook) AfterProcess(ctx context.Context, name string) {
 		span.End()
 	}
 }
+
+func (_this *hook) BeforeOperation(ctx context.Context, op *common.Operation) context.Context {
+	span, ctx := apm.StartSpan(ctx, op.Name, _this.spanName)
+	if span.Dropped() {
+		return ctx
+	}
+
+	if op.Key != nil {
+		span.Context.SetLabel("key", op.Key)
+	}
+	for name, value := range op.Attributes {
+		span.Context.SetLabel(name, value)
+	}
+	return ctx
+}
+
+func (_this *hook) AfterOperation(ctx context.Context, op *common.Operation) {
+	span := apm.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+
+	if op.Err != nil {
+		span.Outcome = "failure"
+		if e := apm.CaptureError(ctx, op.Err); e != nil {
+			e.Send()
+		}
+	} else {
+		span.Outcome = "success"
+	}
+	span.End()
+}
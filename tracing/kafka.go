@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+
+	"go.elastic.co/apm"
+	"go.elastic.co/apm/module/apmhttp"
+)
+
+// Headers of Kafka messages carry the W3C trace context
+const (
+	KafkaTraceparentHeader = "traceparent"
+	KafkaTracestateHeader  = "tracestate"
+)
+
+// KafkaTraceHeaders returns the headers carry the trace context of the span or the transaction in ctx,
+// nil is returned when ctx is not traced
+func KafkaTraceHeaders(ctx context.Context) map[string]string {
+	var traceContext apm.TraceContext
+	if span := apm.SpanFromContext(ctx); span != nil {
+		traceContext = span.TraceContext()
+	} else if tx := apm.TransactionFromContext(ctx); tx != nil {
+		traceContext = tx.TraceContext()
+	} else {
+		return nil
+	}
+
+	headers := map[string]string{
+		KafkaTraceparentHeader: apmhttp.FormatTraceparentHeader(traceContext),
+	}
+	if state := traceContext.State.String(); state != "" {
+		headers[KafkaTracestateHeader] = state
+	}
+	return headers
+}
+
+// StartKafkaTransaction starts a transaction of a consumed message, it continues the trace of the producer
+// when headers carry a trace context, otherwise a new trace is started
+func StartKafkaTransaction(ctx context.Context, name string, headers map[string]string) (context.Context, *apm.Transaction) {
+	var opts apm.TransactionOptions
+	if traceparent, ok := headers[KafkaTraceparentHeader]; ok {
+		if traceContext, err := apmhttp.ParseTraceparentHeader(traceparent); err == nil {
+			if tracestate, ok := headers[KafkaTracestateHeader]; ok {
+				traceContext.State, _ = apmhttp.ParseTracestateHeader(tracestate)
+			}
+			opts.TraceContext = traceContext
+		}
+	}
+
+	tx := apm.DefaultTracer.StartTransactionOptions(name, "messaging", opts)
+	return apm.ContextWithTransaction(ctx, tx), tx
+}